@@ -0,0 +1,3 @@
+module github.com/rohe/go-spocp
+
+go 1.22
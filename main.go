@@ -1,95 +1,191 @@
+// Command go-spocp parses SPOCP S-expressions and matches queries against rules.
+//
+// Usage:
+//
+//	go-spocp parse [<s-expression>...]
+//	go-spocp match <rule> <query>...
+//	go-spocp explain <rule> <query>...
+//	go-spocp json [<s-expression>...]
+//	go-spocp canonical [<json>...]
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"os"
+
+	"github.com/rohe/go-spocp/spocp"
 )
 
-var TAB = []byte{32, 32, 32, 32}
+const usage = `usage:
+	go-spocp parse [<s-expression>...]
+	go-spocp match <rule> <query>...
+	go-spocp explain <rule> <query>...
+	go-spocp json [<s-expression>...]
+	go-spocp canonical [<json>...]`
 
-type Input struct {
-	bs              []byte
-	currentPosition int
-}
+// fatal reports err, including an excerpt of the input for parse errors, and exits
+func fatal(err error) {
+	var parseErr *spocp.ParseError
 
-func (inp Input) Remaining() int {
-	return len(inp.bs) - inp.currentPosition
+	if errors.As(err, &parseErr) {
+		log.Printf("%v\n%s", err, parseErr.Excerpt)
+		log.Fatal("parse failed")
+	}
+	log.Fatal(err)
 }
-func (inp Input) NextByte() byte {
-	return inp.bs[inp.currentPosition]
+
+// decode calls fn for each of the given S-expressions, or for each one read
+// from stdin if there are none
+func decode(args []string, fn func(node *spocp.Node)) {
+	if len(args) == 0 {
+		dec := spocp.NewDecoder(os.Stdin)
+		for {
+			node, err := dec.Decode()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				fatal(err)
+			}
+			fn(node)
+		}
+	}
+	for _, arg := range args {
+		node, err := spocp.Parse([]byte(arg))
+		if err != nil {
+			fatal(err)
+		}
+		fn(node)
+	}
 }
-func (inp Input) Slice(begin, end int) []byte {
-	return inp.bs[begin:end]
+
+// parse prints the given S-expressions, or those read from stdin if there are none
+func parse(args []string) {
+	printer := spocp.DefaultPrinter
+	printer.Color = colorOutput()
+	decode(args, func(node *spocp.Node) {
+		if err := printer.Fprint(os.Stdout, node); err != nil {
+			fatal(err)
+		}
+		fmt.Println()
+	})
 }
-func (inp Input) Prefix(length int) []byte {
-	return inp.bs[inp.currentPosition : inp.currentPosition+length]
+
+// colorOutput reports whether stdout is a terminal and NO_COLOR is not set
+func colorOutput() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
 }
-func (inp Input) RemainingString() string {
-	return string(inp.bs[inp.currentPosition:])
+
+// toJSON prints the JSON form of the given S-expressions
+func toJSON(args []string) {
+	decode(args, func(node *spocp.Node) {
+		data, err := json.Marshal(node)
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Println(string(data))
+	})
 }
-func (inp Input) RemainingBytes() []byte { return inp.bs[inp.currentPosition:] }
 
-func main() {
-	// s := "(gopher foo)"
-	// s := "(11:certificate(6:issuer3:bob)(7:subject5:alice))"
-	var SExpressions = map[string][]string{
-		// "(11:certificate(6:issuer3:bob)(7:subject))": []string{"(11:certificate(6:issuer3:bob)(7:subject5:alice))"},
-		// "(11:certificate(6:issuer3:bob)(5:level(1:*5:range7:numeric2:le3:100)))": {"(11:certificate(" +
-		//	"6:issuer3:bob)(5:level2:99))", "(11:certificate(6:issuer3:bob)(5:level3:101))"},
-		// "(11:certificate(6:issuer3:bob)(5:level(1:*5:range5:alpha2:ge3:abc)))",
-		// "(11:certificate(6:issuer3:bob)(5:level(1:*5:range4:ipv42:ge11:130.239.1.12:lt13:130.239.1.127)))",
-		// "(11:certificate(6:issuer3:bob)(5:level(1:*5:range4:ipv62:ge39:FEDC:BA98:7654:3210:FEDC:BA98:7654:3210)))",
-		// "(11:certificate(6:issuer3:bob)(5:level(1:*5:range4:ipv62:ge26:1080:0:0:0:8:800:200C:417A)))",
-		// "(11:certificate(6:issuer3:bob)(5:level(1:*5:range4:ipv62:ge21:1080::8:800:200C:417A)))",
-		//
-		// "(11:certificate(6:issuer3:bob)(5:fruit(1:*3:set5:apple6:orange5:lemon)))": {
-		// 	"(11:certificate(6:issuer3:bob)(5:fruit5:apple)))",
-		// 	"(11:certificate(6:issuer3:bob)(5:fruit6:orange)))",
-		// 	"(11:certificate(6:issuer3:bob)(5:fruit5:lemon)))",
-		// 	"(11:certificate(6:issuer3:bob)(5:fruit4:pear)))"},
-		//
-		// "(1:t(1:*3:set(1:a1:b)(1:c(1:d1:e))(1:f)1:g))",
-		//
-		// "(11:certificate(6:issuer3:bob)(4:when(1:*5:range4:date2:ge25:2023-12-22T17:25:33+01:00)))": {
-		// 	"(11:certificate(6:issuer3:bob)(4:when25:2025-03-05T11:00:00+01:00))",
-		// 	"(11:certificate(6:issuer3:bob)(4:when25:2020-03-05T11:00:00+01:00))",
-		// },
-		//
-		// "(11:certificate(6:issuer3:bob)(4:when(1:*5:range4:date2:ge25:2023-12-22T17:25:33+01:002:le25:2030-12-31T23" +
-		// 	":59:59+01:00)))": {
-		// 	"(11:certificate(6:issuer3:bob)(4:when25:2025-03-05T11:00:00+01:00))",
-		// 	"(11:certificate(6:issuer3:bob)(4:when25:2020-03-05T11:00:00+01:00))",
-		// 	"(11:certificate(6:issuer3:bob)(4:when25:2035-03-05T11:00:00+01:00))",
-		// },
-		//
-		"(11:certificate(6:issuer3:bob)(4:when(1:*5:range4:time2:ge8:10:30:00)))": {
-			"(11:certificate(6:issuer3:bob)(4:when8:12:00:00)))",
-			"(11:certificate(6:issuer3:bob)(4:when8:09:00:00)))",
-		},
+// fromJSON prints the canonical form of the given JSON values, or of those
+// read from stdin if there are none
+func fromJSON(args []string) {
+	var nodes []spocp.Node
+
+	if len(args) == 0 {
+		dec := json.NewDecoder(os.Stdin)
+		for {
+			var node spocp.Node
+			err := dec.Decode(&node)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				fatal(err)
+			}
+			nodes = append(nodes, node)
+		}
+	}
+	for _, arg := range args {
+		var node spocp.Node
+		if err := json.Unmarshal([]byte(arg), &node); err != nil {
+			fatal(err)
+		}
+		nodes = append(nodes, node)
+	}
+	for _, node := range nodes {
+		data, err := spocp.Marshal(&node)
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Println(string(data))
 	}
-	for stringRule, QueryList := range SExpressions {
-		var Rule, Query *Node
-		var err error
-		var cmp bool
+}
 
-		// Skip the first '('
-		var inp = Input{[]byte(stringRule), 1}
-		brackets := 1
+func match(args []string) {
+	if len(args) < 2 {
+		log.Fatal(usage)
+	}
+	rule, err := spocp.Parse([]byte(args[0]))
+	if err != nil {
+		fatal(err)
+	}
+	for _, arg := range args[1:] {
+		query, err := spocp.Parse([]byte(arg))
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Printf("%s: %v\n", arg, spocp.LessOrEqualTo(*query, *rule))
+	}
+}
 
-		Rule, err = GetSexp(&inp, &brackets)
+// explain prints the decision for each query with the comparisons it was made from
+func explain(args []string) {
+	if len(args) < 2 {
+		log.Fatal(usage)
+	}
+	rule, err := spocp.Parse([]byte(args[0]))
+	if err != nil {
+		fatal(err)
+	}
+	for _, arg := range args[1:] {
+		query, err := spocp.Parse([]byte(arg))
 		if err != nil {
-			log.Fatal("Parse error")
+			fatal(err)
 		}
-		for _, query := range QueryList {
-			println(query)
-			// Skip the first '('
-			inp = Input{[]byte(query), 1}
-			brackets = 1
-			Query, err = GetSexp(&inp, &brackets)
-			if err != nil {
-				log.Fatal("Parse error")
-			}
-			cmp, err = Query.Compare(*Rule)
-			println(cmp)
+		explanation := spocp.Explain(*query, *rule)
+		fmt.Printf("%s: %v\n", arg, explanation.Decision)
+		for _, step := range explanation.Steps {
+			fmt.Printf("\t%-6s %v\n", step.Comparator, step)
 		}
 	}
 }
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+	switch os.Args[1] {
+	case "parse":
+		parse(os.Args[2:])
+	case "match":
+		match(os.Args[2:])
+	case "explain":
+		explain(os.Args[2:])
+	case "json":
+		toJSON(os.Args[2:])
+	case "canonical":
+		fromJSON(os.Args[2:])
+	default:
+		log.Fatal(usage)
+	}
+}
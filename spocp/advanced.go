@@ -0,0 +1,359 @@
+package spocp
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+)
+
+// tokenPunctuation holds the non alphanumeric characters allowed in a token
+var tokenPunctuation = []byte("-./_:*+=")
+
+// offsetMapping connects a string in the canonical form to the place in the
+// advanced form it was read from
+type offsetMapping struct {
+	canonical int
+	advanced  int
+}
+
+// advancedInput converts the advanced form of an S-expression into the
+// canonical form, keeping track of where every canonical string came from.
+type advancedInput struct {
+	input
+	out     []byte
+	offsets []offsetMapping
+	// tags of the open lists, "" while the tag has not been read
+	tags []string
+}
+
+// ParseAdvanced parses a single S-expression in advanced form, e.g.
+// (certificate (issuer bob) (level (* range numeric ge 100))).
+// Strings can be given as tokens, "quoted strings", #hex#, |base64| or in
+// verbatim form 3:bob. A string that starts with digits followed by ':' is
+// read as verbatim, values such as times, 10:30:00, must be quoted.
+// A string can be preceded by a display hint, [image/png]|iVBORw0K...|.
+// The input is parsed within DefaultLimits.
+func ParseAdvanced(data []byte) (*Node, error) {
+	p := Parser{Limits: DefaultLimits}
+	return p.ParseAdvanced(data)
+}
+
+// ParseAdvanced parses a single S-expression in advanced form, see ParseAdvanced
+func (p *Parser) ParseAdvanced(data []byte) (*Node, error) {
+	if err := p.Limits.checkSize(&input{bs: data}); err != nil {
+		return nil, err
+	}
+	return p.parseAdvanced(data)
+}
+
+func (p *Parser) parseAdvanced(data []byte) (*Node, error) {
+	var parseErr *ParseError
+
+	adv := advancedInput{input: input{bs: data}}
+	err := adv.convert()
+	if err != nil {
+		return nil, err
+	}
+	node, err := p.parseCanonical(adv.out)
+	if errors.As(err, &parseErr) {
+		parseErr.relocate(data, adv.advancedOffset(parseErr.Offset))
+	}
+	return node, err
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isTokenChar(c byte) bool {
+	if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || digit(c) {
+		return true
+	}
+	for _, p := range tokenPunctuation {
+		if c == p {
+			return true
+		}
+	}
+	return false
+}
+
+// advancedOffset maps a position in the canonical output back to the input
+func (adv *advancedInput) advancedOffset(pos int) int {
+	n := sort.Search(len(adv.offsets), func(i int) bool {
+		return adv.offsets[i].canonical > pos
+	})
+	if n == 0 {
+		return 0
+	}
+	return adv.offsets[n-1].advanced
+}
+
+func (adv *advancedInput) skipSpace() {
+	for adv.Remaining() > 0 && isSpace(adv.NextByte()) {
+		adv.currentPosition++
+	}
+}
+
+func (adv *advancedInput) fail(pos int, expected string, err error) error {
+	adv.path = adv.path[:0]
+	for _, tag := range adv.tags {
+		if tag != "" {
+			adv.path = append(adv.path, tag)
+		}
+	}
+	return newParseError(&adv.input, pos, expected, err)
+}
+
+// mark records that the canonical output from here on was read at pos
+func (adv *advancedInput) mark(pos int) {
+	adv.offsets = append(adv.offsets, offsetMapping{len(adv.out), pos})
+}
+
+// emit writes a string to the canonical output and tracks list tags
+func (adv *advancedInput) emit(pos int, oct *OctetString) {
+	adv.mark(pos)
+	adv.out = appendOctetString(adv.out, oct)
+	if len(adv.tags) == 0 {
+		return
+	}
+	top := &adv.tags[len(adv.tags)-1]
+	if *top == "" {
+		*top = string(oct.Value)
+	} else if *top == "*" {
+		*top = "*" + string(oct.Value)
+	}
+}
+
+func (adv *advancedInput) convert() error {
+	adv.skipSpace()
+	if adv.Remaining() == 0 || adv.NextByte() != LeftBracket {
+		return adv.fail(adv.currentPosition, "'('", nil)
+	}
+	for {
+		adv.skipSpace()
+		if adv.Remaining() == 0 {
+			return adv.fail(adv.currentPosition, "')'", nil)
+		}
+		start := adv.currentPosition
+		switch c := adv.NextByte(); {
+		case c == LeftBracket:
+			adv.mark(start)
+			adv.out = append(adv.out, LeftBracket)
+			adv.tags = append(adv.tags, "")
+			adv.currentPosition++
+		case c == RightBracket:
+			adv.mark(start)
+			adv.out = append(adv.out, RightBracket)
+			adv.tags = adv.tags[:len(adv.tags)-1]
+			adv.currentPosition++
+			if len(adv.tags) == 0 {
+				adv.skipSpace()
+				if adv.Remaining() > 0 {
+					return adv.fail(adv.currentPosition, "end of input", nil)
+				}
+				return nil
+			}
+		default:
+			oct, err := adv.octetString()
+			if err != nil {
+				return err
+			}
+			adv.emit(start, oct)
+		}
+	}
+}
+
+// octetString reads a string with an optional display hint, [text/plain]alice
+func (adv *advancedInput) octetString() (*OctetString, error) {
+	var oct OctetString
+	var err error
+
+	if adv.NextByte() == LeftSquareBracket {
+		adv.currentPosition++
+		adv.skipSpace()
+		if adv.Remaining() == 0 {
+			return nil, adv.fail(adv.currentPosition, "display hint", nil)
+		}
+		oct.Hint, err = adv.simpleString()
+		if err != nil {
+			return nil, err
+		}
+		adv.skipSpace()
+		if adv.Remaining() == 0 || adv.NextByte() != RightSquareBracket {
+			return nil, adv.fail(adv.currentPosition, "']'", nil)
+		}
+		adv.currentPosition++
+		adv.skipSpace()
+		if adv.Remaining() == 0 {
+			return nil, adv.fail(adv.currentPosition, "string", nil)
+		}
+	}
+	oct.Value, err = adv.simpleString()
+	if err != nil {
+		return nil, err
+	}
+	return &oct, nil
+}
+
+// simpleString reads one string in any of the advanced encodings
+func (adv *advancedInput) simpleString() ([]byte, error) {
+	start := adv.currentPosition
+	length := -1
+
+	if digit(adv.NextByte()) {
+		end := start
+		for end < len(adv.bs) && digit(adv.bs[end]) {
+			end++
+		}
+		if end < len(adv.bs) && (adv.bs[end] == ':' || adv.bs[end] == '"' || adv.bs[end] == '#' || adv.bs[end] == '|') {
+			var err error
+			length, err = strconv.Atoi(string(adv.bs[start:end]))
+			if err != nil {
+				return nil, adv.fail(start, "length", err)
+			}
+			adv.currentPosition = end
+		}
+	}
+	if adv.Remaining() == 0 {
+		return nil, adv.fail(adv.currentPosition, "string", nil)
+	}
+
+	var value []byte
+	var err error
+	switch c := adv.NextByte(); {
+	case c == ':' && length >= 0:
+		adv.currentPosition++
+		if adv.Remaining() < length {
+			return nil, adv.fail(adv.currentPosition, "octet string of "+strconv.Itoa(length)+" bytes", nil)
+		}
+		value = adv.Prefix(length)
+		adv.currentPosition += length
+		return value, nil
+	case c == '"':
+		value, err = adv.quoted()
+	case c == '#':
+		value, err = adv.encoded('#', func(text []byte) ([]byte, error) {
+			return hex.DecodeString(string(text))
+		})
+	case c == '|':
+		value, err = adv.encoded('|', decodeBase64)
+	case isTokenChar(c) && length < 0:
+		for adv.Remaining() > 0 && isTokenChar(adv.NextByte()) {
+			adv.currentPosition++
+		}
+		return adv.Slice(start, adv.currentPosition), nil
+	default:
+		return nil, adv.fail(adv.currentPosition, "string", nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if length >= 0 && length != len(value) {
+		return nil, adv.fail(start, "string of "+strconv.Itoa(length)+" bytes", nil)
+	}
+	return value, nil
+}
+
+// encoded reads a hex or base64 string delimited by delim, whitespace is ignored
+func (adv *advancedInput) encoded(delim byte, decode func([]byte) ([]byte, error)) ([]byte, error) {
+	var text []byte
+
+	start := adv.currentPosition
+	adv.currentPosition++
+	for {
+		if adv.Remaining() == 0 {
+			return nil, adv.fail(start, "closing '"+string(delim)+"'", nil)
+		}
+		c := adv.NextByte()
+		adv.currentPosition++
+		if c == delim {
+			break
+		}
+		if !isSpace(c) {
+			text = append(text, c)
+		}
+	}
+	value, err := decode(text)
+	if err != nil {
+		return nil, adv.fail(start+1, "encoded string", err)
+	}
+	return value, nil
+}
+
+func decodeBase64(text []byte) ([]byte, error) {
+	if len(text)%4 != 0 {
+		return base64.RawStdEncoding.DecodeString(string(text))
+	}
+	return base64.StdEncoding.DecodeString(string(text))
+}
+
+// quoted reads a quoted string, handling the escapes of the advanced form
+func (adv *advancedInput) quoted() ([]byte, error) {
+	var value []byte
+
+	start := adv.currentPosition
+	adv.currentPosition++
+	for {
+		if adv.Remaining() == 0 {
+			return nil, adv.fail(start, "closing '\"'", nil)
+		}
+		c := adv.NextByte()
+		adv.currentPosition++
+		if c == '"' {
+			return value, nil
+		}
+		if c != '\\' {
+			value = append(value, c)
+			continue
+		}
+		if adv.Remaining() == 0 {
+			return nil, adv.fail(start, "closing '\"'", nil)
+		}
+		escape := adv.currentPosition
+		c = adv.NextByte()
+		adv.currentPosition++
+		switch c {
+		case 'b':
+			value = append(value, '\b')
+		case 't':
+			value = append(value, '\t')
+		case 'v':
+			value = append(value, '\v')
+		case 'n':
+			value = append(value, '\n')
+		case 'f':
+			value = append(value, '\f')
+		case 'r':
+			value = append(value, '\r')
+		case '"', '\'', '\\':
+			value = append(value, c)
+		case '\n', '\r':
+			// line continuation, a \r\n or \n\r pair counts as one
+			if adv.Remaining() > 0 && (adv.NextByte() == '\n' || adv.NextByte() == '\r') && adv.NextByte() != c {
+				adv.currentPosition++
+			}
+		case 'x':
+			if adv.Remaining() < 2 {
+				return nil, adv.fail(escape, "two hex digits", nil)
+			}
+			b, err := strconv.ParseUint(string(adv.Prefix(2)), 16, 8)
+			if err != nil {
+				return nil, adv.fail(escape+1, "two hex digits", nil)
+			}
+			value = append(value, byte(b))
+			adv.currentPosition += 2
+		default:
+			if c < '0' || c > '7' || adv.Remaining() < 2 {
+				return nil, adv.fail(escape, "escape sequence", nil)
+			}
+			b, err := strconv.ParseUint(string(adv.Slice(escape, escape+3)), 8, 8)
+			if err != nil {
+				return nil, adv.fail(escape, "three octal digits", nil)
+			}
+			value = append(value, byte(b))
+			adv.currentPosition += 2
+		}
+	}
+}
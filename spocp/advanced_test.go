@@ -0,0 +1,75 @@
+package spocp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseAdvanced(t *testing.T) {
+	var s_expressions = map[string]string{
+		"(certificate (issuer bob) (level (* range numeric ge 100)))": "(11:certificate(6:issuer3:bob)(5:level(1:*5:range7:numeric2:ge3:100)))",
+		"  (certificate\n\t(issuer bob)\n\t(subject alice))\n":        "(11:certificate(6:issuer3:bob)(7:subject5:alice))",
+		"(certificate (issuer \"Bob \\\"B\\\" Smith\\n\"))":           "(11:certificate(6:issuer14:Bob \"B\" Smith\n))",
+		"(key #01 02 ff#)":                      "(3:key3:\x01\x02\xff)",
+		"(key 3#0102ff#)":                       "(3:key3:\x01\x02\xff)",
+		"(key |AQL/|)":                          "(3:key3:\x01\x02\xff)",
+		"(key |AQ|)":                            "(3:key1:\x01)",
+		"(key 5:a b c)":                         "(3:key5:a b c)",
+		"(when (* range time ge \"10:30:00\"))": "(4:when(1:*5:range4:time2:ge8:10:30:00))",
+		"(net (* range ipv4 ge 130.239.1.1 lt 130.239.1.127))": "(3:net(1:*5:range4:ipv42:ge11:130.239.1.12:lt13:130.239.1.127))",
+		"(fruit (* set apple orange (tree (leaf green))))":     "(5:fruit(1:*3:set5:apple6:orange(4:tree(4:leaf5:green))))",
+		"(cert [application/pkix-cert] |MIIBCg==|)":            "(4:cert[21:application/pkix-cert]4:\x30\x82\x01\x0a)",
+		"(user [ text/plain ] alice)":                          "(4:user[10:text/plain]5:alice)",
+		"(esc \"\\x41\\101\\t\\\n\")":                          "(3:esc3:AA\t)",
+		"(11:certificate (issuer bob))":                        "(11:certificate(6:issuer3:bob))",
+		"(5:level 100)":                                        "(5:level3:100)",
+		"(10 apples)":                                          "(2:106:apples)",
+	}
+	for expression, canonical := range s_expressions {
+		node, err := Parse([]byte(expression))
+		if err != nil {
+			t.Errorf("%s: %v", expression, err)
+			continue
+		}
+		out, err := Marshal(node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != canonical {
+			t.Errorf("%s: expected %q, got %q", expression, canonical, out)
+		}
+	}
+}
+
+func TestParseAdvancedMalformed(t *testing.T) {
+	var s_expressions = map[string]struct {
+		offset int
+		path   string
+	}{
+		"certificate":                                {0, ""},
+		"(certificate (issuer bob)":                  {25, "certificate"},
+		"(certificate (issuer bob)) (x)":             {27, ""},
+		"(certificate (issuer \"bob))":               {21, "certificate/issuer"},
+		"(certificate (issuer #0g#))":                {22, "certificate/issuer"},
+		"(certificate (issuer 4\"bob\"))":            {21, "certificate/issuer"},
+		"(certificate (issuer bob) (when 10:30:00))": {35, "certificate/when"},
+		"(level (* range numeric xx 100))":           {24, "level/*range"},
+		"(level (* range numeric ge 1a0))":           {27, "level/*range"},
+	}
+	for expression, expected := range s_expressions {
+		var parseErr *ParseError
+
+		_, err := ParseAdvanced([]byte(expression))
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%s: expected a ParseError, got %v", expression, err)
+			continue
+		}
+		if parseErr.Offset != expected.offset {
+			t.Errorf("%s: expected offset %d, got %d (%v)", expression, expected.offset, parseErr.Offset, err)
+		}
+		if path := strings.Join(parseErr.Path, "/"); path != expected.path {
+			t.Errorf("%s: expected path %s, got %s", expression, expected.path, path)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package spocp
+
+import (
+	"fmt"
+)
+
+// Bound is one limit of a range, e.g. Bound{"ge", []byte("100")}
+type Bound struct {
+	// Boundary is one of le, lt, ge or gt
+	Boundary string
+	Value    []byte
+}
+
+// Atom returns an octet string holding value
+func Atom(value []byte) Node {
+	return newOctetString(&OctetString{Value: value})
+}
+
+// List returns an s-expression with the given tag followed by children,
+// List("issuer", Atom([]byte("bob"))) is (6:issuer3:bob)
+func List(tag string, children ...Node) (Node, error) {
+	if tag == "*" {
+		return Node{}, fmt.Errorf("list: tag %q is reserved for star forms", tag)
+	}
+	for i, child := range children {
+		if child.kind == KindInvalid {
+			return Node{}, fmt.Errorf("list %s: part %d is not a valid node", tag, i)
+		}
+	}
+	return newSExpression(&OctetString{Value: []byte(tag)}, children), nil
+}
+
+// SetOf returns the star form set of members. A set has at least one
+// member and no two s-expressions with the same tag or two equal octet
+// strings.
+func SetOf(members ...Node) (Node, error) {
+	if len(members) == 0 {
+		return Node{}, fmt.Errorf("set: no members")
+	}
+	seen := newSetMembers()
+	for i := range members {
+		if members[i].kind == KindInvalid {
+			return Node{}, fmt.Errorf("set: member %d is not a valid node", i)
+		}
+		if expected := seen.add(&members[i]); expected != "" {
+			return Node{}, fmt.Errorf("set: member %d: expected %s", i, expected)
+		}
+	}
+	return newSet(members), nil
+}
+
+// RangeOf returns the star form range of the given value type, one of
+// ALPHA, NUMERIC, DATE, TIME, IPV4 or IPV6, bounded by one or two limits
+func RangeOf(valueType string, bounds ...Bound) (Node, error) {
+	if RangeTypeName(valueType) == nil {
+		return Node{}, fmt.Errorf("range: unknown type %q", valueType)
+	}
+	if len(bounds) == 0 || len(bounds) > 2 {
+		return Node{}, fmt.Errorf("range: expected one or two bounds, got %d", len(bounds))
+	}
+	rng := Range{valueType: valueType}
+	for n, bound := range bounds {
+		if !correctLimit(bound.Boundary) {
+			return Node{}, fmt.Errorf("range: boundary %q is not le, lt, ge or gt", bound.Boundary)
+		}
+		if err := verifyLimit(&rng, bound.Value, n); err != nil {
+			return Node{}, fmt.Errorf("range: %s limit %q: %w", valueType, bound.Value, err)
+		}
+		rng.boundary[n] = bound.Boundary
+		rng.rawLimit[n] = bound.Value
+	}
+	if len(bounds) == 2 {
+		if err := verifyBounds(&rng); err != nil {
+			return Node{}, fmt.Errorf("range: %w", err)
+		}
+	}
+	return newRange(&rng), nil
+}
+
+// PrefixOf returns the star form matching strings that start with value
+func PrefixOf(value []byte) Node {
+	return newPrefix(value)
+}
+
+// SuffixOf returns the star form matching strings that end with value
+func SuffixOf(value []byte) Node {
+	return newSuffix(value)
+}
@@ -0,0 +1,89 @@
+package spocp
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	fruit, err := SetOf(Atom([]byte("apple")), Atom([]byte("lemon")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	level, err := RangeOf(NUMERIC, Bound{"ge", []byte("10")}, Bound{"lt", []byte("100")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer, err := List("issuer", Atom([]byte("bob")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := List("certificate", issuer, fruit, level, PrefixOf([]byte("ab")), SuffixOf(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "(11:certificate(6:issuer3:bob)(1:*3:set5:apple5:lemon)(1:*5:range7:numeric2:ge2:102:lt3:100)" +
+		"(1:*6:prefix2:ab)(1:*6:suffix0:))"
+	got, err := Marshal(&node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != expected {
+		t.Fatalf("expected %s, got %s", expected, got)
+	}
+	parsed, err := Parse(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := parsed.Parts()[2].Range().rawLimit, level.Range().rawLimit; !bytes.Equal(got[0], want[0]) || !bytes.Equal(got[1], want[1]) {
+		t.Errorf("expected limits %q, got %q", want, got)
+	}
+}
+
+func TestBuilderInvalid(t *testing.T) {
+	a := Atom([]byte("a"))
+	list, _ := List("a")
+	builders := map[string]func() (Node, error){
+		"star tag":              func() (Node, error) { return List("*", a) },
+		"invalid part":          func() (Node, error) { return List("a", Node{}) },
+		"empty set":             func() (Node, error) { return SetOf() },
+		"duplicate octet":       func() (Node, error) { return SetOf(a, Atom([]byte("a"))) },
+		"duplicate tag":         func() (Node, error) { return SetOf(a, list, list) },
+		"invalid member":        func() (Node, error) { return SetOf(a, Node{}) },
+		"unknown range type":    func() (Node, error) { return RangeOf("numeric", Bound{"ge", []byte("1")}) },
+		"no bounds":             func() (Node, error) { return RangeOf(NUMERIC) },
+		"three bounds":          func() (Node, error) { b := Bound{"ge", []byte("1")}; return RangeOf(NUMERIC, b, b, b) },
+		"unknown boundary":      func() (Node, error) { return RangeOf(NUMERIC, Bound{"eq", []byte("1")}) },
+		"not a number":          func() (Node, error) { return RangeOf(NUMERIC, Bound{"ge", []byte("x")}) },
+		"ipv6 in an ipv4 range": func() (Node, error) { return RangeOf(IPV4, Bound{"ge", []byte("::1")}) },
+		"empty number":          func() (Node, error) { return RangeOf(NUMERIC, Bound{"ge", nil}) },
+		"two lower bounds": func() (Node, error) {
+			return RangeOf(NUMERIC, Bound{"ge", []byte("10")}, Bound{"gt", []byte("5")})
+		},
+		"two upper bounds": func() (Node, error) {
+			return RangeOf(DATE, Bound{"le", []byte("2024-01-01T00:00:00Z")}, Bound{"lt", []byte("2025-01-01T00:00:00Z")})
+		},
+		"empty range": func() (Node, error) {
+			return RangeOf(NUMERIC, Bound{"ge", []byte("10")}, Bound{"le", []byte("5")})
+		},
+		"no integer within": func() (Node, error) {
+			return RangeOf(NUMERIC, Bound{"gt", []byte("5")}, Bound{"lt", []byte("6")})
+		},
+		"open at one value": func() (Node, error) {
+			return RangeOf(ALPHA, Bound{"ge", []byte("a")}, Bound{"lt", []byte("a")})
+		},
+	}
+	for name, build := range builders {
+		if _, err := build(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	// the octet string and the s-expression may share a value
+	if _, err := SetOf(a, list); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := RangeOf(IPV4, Bound{"le", []byte("10.0.0.1")}, Bound{"gt", []byte("10.0.0.0")}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
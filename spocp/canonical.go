@@ -0,0 +1,93 @@
+package spocp
+
+import (
+	"fmt"
+	"strconv"
+)
+
+var Star = []byte{'*'}
+
+// AppendCanonical appends the canonical form of node to dst and returns
+// the extended buffer. The output parses back into the same Node.
+func AppendCanonical(dst []byte, node *Node) ([]byte, error) {
+	return appendNode(dst, *node)
+}
+
+func appendOctet(dst []byte, value []byte) []byte {
+	dst = strconv.AppendInt(dst, int64(len(value)), 10)
+	dst = append(dst, ':')
+	return append(dst, value...)
+}
+
+// appendOctetString appends oct, preceded by its display hint if it has one
+func appendOctetString(dst []byte, oct *OctetString) []byte {
+	if oct.Hint != nil {
+		dst = append(dst, LeftSquareBracket)
+		dst = appendOctet(dst, oct.Hint)
+		dst = append(dst, RightSquareBracket)
+	}
+	return appendOctet(dst, oct.Value)
+}
+
+func appendNode(dst []byte, node Node) ([]byte, error) {
+	var err error
+
+	switch node.kind {
+	case KindSExpression:
+		dst = append(dst, LeftBracket)
+		dst = appendOctetString(dst, node.octet)
+		for _, part := range node.parts {
+			dst, err = appendNode(dst, part)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return append(dst, RightBracket), nil
+	case KindOctetString:
+		return appendOctetString(dst, node.octet), nil
+	case KindSet:
+		dst = appendStarForm(dst, SetStarform)
+		for _, member := range node.parts {
+			dst, err = appendNode(dst, member)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return append(dst, RightBracket), nil
+	case KindRange:
+		return appendRange(dst, node.rng)
+	case KindPrefix:
+		dst = appendStarForm(dst, PrefixStarform)
+		dst = appendOctet(dst, node.octet.Value)
+		return append(dst, RightBracket), nil
+	case KindSuffix:
+		dst = appendStarForm(dst, SuffixStarform)
+		dst = appendOctet(dst, node.octet.Value)
+		return append(dst, RightBracket), nil
+	}
+	return nil, fmt.Errorf("marshal: node has no value")
+}
+
+// appendStarForm appends the opening of a star form of the given type
+func appendStarForm(dst []byte, starForm string) []byte {
+	dst = append(dst, LeftBracket)
+	dst = appendOctet(dst, Star)
+	return appendOctet(dst, []byte(starForm))
+}
+
+func appendRange(dst []byte, rng *Range) ([]byte, error) {
+	rangeType := RangeTypeName(rng.valueType)
+	if rangeType == nil {
+		return nil, fmt.Errorf("marshal: unknown range type %q", rng.valueType)
+	}
+	dst = appendStarForm(dst, RangeStarform)
+	dst = appendOctet(dst, rangeType)
+	for n := range rng.boundary {
+		if rng.boundary[n] == "" {
+			break
+		}
+		dst = appendOctet(dst, []byte(rng.boundary[n]))
+		dst = appendOctet(dst, rng.rawLimit[n])
+	}
+	return append(dst, RightBracket), nil
+}
@@ -0,0 +1,477 @@
+package spocp
+
+import (
+	"bytes"
+	"fmt"
+	"net/netip"
+	"time"
+)
+
+type Compare interface {
+	Comp(any) int
+}
+
+// The compare functions implement the SPOCP order: they decide whether
+// query is less than or equal to rule, that is whether rule permits query.
+// Values that do not compare are no match, OutcomeError is reserved for
+// comparisons that cannot be made.
+
+// SExpressionCompare compares two lists. The tags must be equal and every
+// part of the rule must permit the part of the query at the same place. A
+// query with more parts than the rule is more specific and is permitted, a
+// query with fewer parts is not.
+func SExpressionCompare(query, rule Node) Decision {
+	return sexpressionCompare(query, rule, nil)
+}
+
+func sexpressionCompare(query, rule Node, tr *tracer) Decision {
+	tr.enter(string(query.octet.Value))
+	defer tr.leave()
+
+	// compare tag
+	if d := OctetStringCompare(query.octet, rule.octet); !d.Matched() {
+		tr.record("list", query, rule, d)
+		return d
+	}
+	return compareSequence(query.parts, rule.parts, tr)
+}
+
+func OctetCompare(query, rule []byte) Decision {
+	return decide(bytes.Equal(query, rule))
+}
+
+// OctetStringCompare compares two octet strings including their display
+// hints. A rule without a hint matches a query with any hint, or none,
+// while a rule with a hint only matches a query carrying the same hint. An
+// empty hint is a hint, a query without one does not match it.
+func OctetStringCompare(query, rule *OctetString) Decision {
+	if rule.Hint != nil && (query.Hint == nil || !bytes.Equal(query.Hint, rule.Hint)) {
+		return noMatch
+	}
+	return OctetCompare(query.Value, rule.Value)
+}
+
+// NodeToSetCompare decides whether query is less than or equal to at least
+// one member of the rule set
+func NodeToSetCompare(query Node, rule []Node) Decision {
+	return nodeToSetCompare(query, rule, nil)
+}
+
+func nodeToSetCompare(query Node, rule []Node, tr *tracer) Decision {
+	d := noMatch
+	// the members that do not match are no explanation
+	tr.mute()
+	for _, member := range rule {
+		if d = lessOrEqualTo(query, member, tr); d.Outcome != OutcomeNoMatch {
+			break
+		}
+	}
+	tr.unmute()
+	tr.record("set", query, newSet(rule), d)
+	return d
+}
+
+func OctetToSetCompare(query *OctetString, rule []Node) Decision {
+	return NodeToSetCompare(newOctetString(query), rule)
+}
+
+// SetToSetCompare decides whether every member of the query set is less
+// than or equal to a member of the rule set
+func SetToSetCompare(query []Node, rule []Node) Decision {
+	return setToSetCompare(query, rule, nil)
+}
+
+func setToSetCompare(query []Node, rule []Node, tr *tracer) Decision {
+	for _, member := range query {
+		// a set within the query set is split into its members
+		if d := lessOrEqualTo(member, newSet(rule), tr); !d.Matched() {
+			return d
+		}
+	}
+	return matched
+}
+
+// RangeCompare decides whether every value within the query range is within
+// the rule range. The ranges must be of the same type and every limit of the
+// rule must be matched by a limit of the query on the same side that is as
+// strict or stricter, so a query without an upper limit is only permitted by
+// a rule without one.
+func RangeCompare(query, rule *Range) Decision {
+	if query.valueType != rule.valueType {
+		return noMatch
+	}
+	for num, boundary := range rule.boundary {
+		if boundary == "" {
+			break
+		}
+		d := noMatch
+		for n, limit := range query.boundary {
+			if limit == "" {
+				break
+			}
+			if lowerBoundary(limit) != lowerBoundary(boundary) {
+				continue
+			}
+			if d = withinRangeBoundary(query, rule, n, num); d.Outcome != OutcomeNoMatch {
+				break
+			}
+		}
+		if !d.Matched() {
+			return d
+		}
+	}
+	return matched
+}
+
+// lowerBoundary reports whether boundary is a lower limit, ge or gt
+func lowerBoundary(boundary string) bool {
+	return boundary == "ge" || boundary == "gt"
+}
+
+// withinRangeBoundary decides whether limit n of the query is within limit
+// num of the rule, both on the same side. An open limit is within a closed
+// one at the same value, a closed limit is not within an open one. Numeric
+// and IP limits are made closed first, gt 17 admits the same integers as
+// ge 18.
+func withinRangeBoundary(query, rule *Range, n, num int) Decision {
+	var empty bool
+	if query, empty = closedLimit(query, n); empty {
+		// no value of the query is beyond the limit
+		return matched
+	}
+	if rule, empty = closedLimit(rule, num); empty {
+		return noMatch
+	}
+	cmp, err := compareLimits(query, rule, n, num)
+	if err != nil {
+		return failed(err)
+	}
+	if cmp == 0 && (query.boundary[n] == "lt" || query.boundary[n] == "gt") {
+		return matched
+	}
+	return withinBoundary(rule.boundary[num], cmp)
+}
+
+// closedLimit returns a copy of a numeric or IP range with limit n replaced
+// by the le or ge limit admitting the same values, other ranges as they are.
+// empty is set if the limit admits no value, e.g. lt 0.
+func closedLimit(rng *Range, n int) (closed *Range, empty bool) {
+	boundary := rng.boundary[n]
+	if boundary != "lt" && boundary != "gt" {
+		return rng, false
+	}
+	copied := *rng
+	switch rng.valueType {
+	case NUMERIC:
+		if boundary == "gt" {
+			copied.rawLimit[n] = incrementDigits(rng.rawLimit[n])
+		} else {
+			copied.rawLimit[n], empty = decrementDigits(rng.rawLimit[n])
+		}
+	case IPV4, IPV6:
+		limits := &copied.ipv4Limit
+		if rng.valueType == IPV6 {
+			limits = &copied.ipv6Limit
+		}
+		if boundary == "gt" {
+			limits[n] = limits[n].Next()
+		} else {
+			limits[n] = limits[n].Prev()
+		}
+		empty = !limits[n].IsValid()
+	default:
+		return rng, false
+	}
+	if boundary == "gt" {
+		copied.boundary[n] = "ge"
+	} else {
+		copied.boundary[n] = "le"
+	}
+	return &copied, empty
+}
+
+// incrementDigits returns the number given as decimal digits plus one
+func incrementDigits(digits []byte) []byte {
+	// a leading zero leaves room for a carry
+	sum := append([]byte{'0'}, digits...)
+	for i := len(sum) - 1; i >= 0; i-- {
+		if sum[i] < '9' {
+			sum[i]++
+			break
+		}
+		sum[i] = '0'
+	}
+	return sum
+}
+
+// decrementDigits returns the number given as decimal digits minus one,
+// empty is set for zero
+func decrementDigits(digits []byte) (difference []byte, empty bool) {
+	digits = bytes.TrimLeft(digits, "0")
+	if len(digits) == 0 {
+		return nil, true
+	}
+	difference = bytes.Clone(digits)
+	for i := len(difference) - 1; i >= 0; i-- {
+		if difference[i] > '0' {
+			difference[i]--
+			break
+		}
+		difference[i] = '9'
+	}
+	return difference, false
+}
+
+// compareLimits compares limit n of the query with limit num of the rule,
+// both ranges of the same type
+func compareLimits(query, rule *Range, n, num int) (int, error) {
+	switch rule.valueType {
+	case ALPHA:
+		return rule.compareAlpha(query.rawLimit[n], rule.rawLimit[num]), nil
+	case NUMERIC:
+		return compareDigits(query.rawLimit[n], rule.rawLimit[num]), nil
+	case DATE:
+		return query.dateLimit[n].Compare(rule.dateLimit[num]), nil
+	case TIME:
+		return query.timeLimit[n].Compare(rule.timeLimit[num]), nil
+	case IPV4:
+		return query.ipv4Limit[n].Compare(rule.ipv4Limit[num]), nil
+	case IPV6:
+		return query.ipv6Limit[n].Compare(rule.ipv6Limit[num]), nil
+	}
+	return 0, fmt.Errorf("invalid range comparison")
+}
+
+// withinBoundary decides whether a value that compares as cmp to a limit,
+// -1, 0 or 1, is within the boundary
+func withinBoundary(boundary string, cmp int) Decision {
+	switch boundary {
+	case "le":
+		return decide(cmp <= 0)
+	case "lt":
+		return decide(cmp < 0)
+	case "ge":
+		return decide(cmp >= 0)
+	case "gt":
+		return decide(cmp > 0)
+	}
+	return failed(fmt.Errorf("invalid range boundary %q", boundary))
+}
+
+// compareDigits compares two non-negative numbers of any size given as
+// decimal digits
+func compareDigits(a, b []byte) int {
+	a = bytes.TrimLeft(a, "0")
+	b = bytes.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return cmpInt(len(a), len(b))
+	}
+	return bytes.Compare(a, b)
+}
+
+func cmpInt(a, b int) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+// Collator orders the values of an alpha range, Compare returns -1, 0 or 1
+// like bytes.Compare. The Collator of golang.org/x/text/collate gives a
+// locale-aware order.
+type Collator interface {
+	Compare(a, b []byte) int
+}
+
+type caseInsensitive struct{}
+
+func (caseInsensitive) Compare(a, b []byte) int {
+	return bytes.Compare(bytes.ToLower(a), bytes.ToLower(b))
+}
+
+// CaseInsensitive orders alpha values by byte order ignoring case
+var CaseInsensitive Collator = caseInsensitive{}
+
+// SetCollator sets the order of the values of an alpha range, nil restores
+// byte order. The collator is not part of the S-expression, so it must be
+// set again on a range that is parsed or decoded.
+func (rng *Range) SetCollator(collator Collator) {
+	rng.collator = collator
+}
+
+func (rng *Range) compareAlpha(a, b []byte) int {
+	if rng.collator == nil {
+		return bytes.Compare(a, b)
+	}
+	return rng.collator.Compare(a, b)
+}
+
+// AlphaRangeCompare compares query with limit num of an alpha range, in
+// byte order unless the range has a collator
+func AlphaRangeCompare(query *OctetString, rule *Range, num int) Decision {
+	return withinBoundary(rule.boundary[num], rule.compareAlpha(query.Value, rule.rawLimit[num]))
+}
+
+func NumericRangeCompare(query *OctetString, rule *Range, num int) Decision {
+	if !digits(query.Value) || len(query.Value) == 0 {
+		// not a number, so not within a numeric range
+		return noMatch
+	}
+	return withinBoundary(rule.boundary[num], compareDigits(query.Value, rule.rawLimit[num]))
+}
+
+func DateRangeCompare(query *OctetString, rule *Range, num int) Decision {
+	tid, err := time.Parse(time.RFC3339, string(query.Value))
+	if err != nil {
+		return noMatch
+	}
+	return withinBoundary(rule.boundary[num], tid.Compare(rule.dateLimit[num]))
+}
+
+func TimeRangeCompare(query *OctetString, rule *Range, num int) Decision {
+	queryTime, err := time.Parse(timeOfDay, string(query.Value))
+	if err != nil {
+		return noMatch
+	}
+	return withinBoundary(rule.boundary[num], queryTime.Compare(rule.timeLimit[num]))
+}
+
+// IPRangeCompare compares an address with a limit of an ipv4 or ipv6 range,
+// an address of the other version is not within the range
+func IPRangeCompare(query *OctetString, rule *Range, num int) Decision {
+	limit := rule.ipv4Limit[num]
+	if rule.valueType == IPV6 {
+		limit = rule.ipv6Limit[num]
+	}
+	addr, err := netip.ParseAddr(string(query.Value))
+	if err != nil || addr.Is4() != limit.Is4() {
+		return noMatch
+	}
+	return withinBoundary(rule.boundary[num], addr.Compare(limit))
+}
+
+// OctetToRangeCompare decides whether query is within all the limits of the
+// rule range
+func OctetToRangeCompare(query *OctetString, rule *Range) Decision {
+	for num, boundary := range rule.boundary {
+		if boundary == "" {
+			break
+		}
+		if d := withinRangeLimit(query, rule, num); !d.Matched() {
+			return d
+		}
+	}
+	return matched
+}
+
+// withinRangeLimit decides whether query is within limit num of the range
+func withinRangeLimit(query *OctetString, rule *Range, num int) Decision {
+	switch rule.valueType {
+	case ALPHA:
+		return AlphaRangeCompare(query, rule, num)
+	case NUMERIC:
+		return NumericRangeCompare(query, rule, num)
+	case DATE:
+		return DateRangeCompare(query, rule, num)
+	case TIME:
+		return TimeRangeCompare(query, rule, num)
+	case IPV4, IPV6:
+		return IPRangeCompare(query, rule, num)
+	}
+	return failed(fmt.Errorf("invalid range comparison"))
+}
+
+// OctetToPrefixCompare decides whether query starts with the rule prefix,
+// a display hint on query is ignored
+func OctetToPrefixCompare(query *OctetString, rule []byte) Decision {
+	return decide(bytes.HasPrefix(query.Value, rule))
+}
+
+// PrefixCompare decides whether every string starting with the query prefix
+// starts with the rule prefix, that is whether the rule prefix is a prefix
+// of the query prefix
+func PrefixCompare(query, rule []byte) Decision {
+	return decide(bytes.HasPrefix(query, rule))
+}
+
+// OctetToSuffixCompare decides whether query ends with the rule suffix,
+// a display hint on query is ignored
+func OctetToSuffixCompare(query *OctetString, rule []byte) Decision {
+	return decide(bytes.HasSuffix(query.Value, rule))
+}
+
+// SuffixCompare decides whether every string ending with the query suffix
+// ends with the rule suffix, that is whether the rule suffix is a suffix
+// of the query suffix
+func SuffixCompare(query, rule []byte) Decision {
+	return decide(bytes.HasSuffix(query, rule))
+}
+
+// LessOrEqualTo decides whether query is less than or equal to rule in
+// the SPOCP order. A set in the query must have all its members permitted,
+// a set in the rule permits what one of its members permits.
+func LessOrEqualTo(query, rule Node) Decision {
+	return lessOrEqualTo(query, rule, nil)
+}
+
+func lessOrEqualTo(query, rule Node, tr *tracer) Decision {
+	var d Decision
+
+	switch {
+	case query.kind == KindInvalid || rule.kind == KindInvalid:
+		d = failed(fmt.Errorf("invalid node comparison"))
+	case query.kind == KindSet && rule.kind == KindSet:
+		return setToSetCompare(query.parts, rule.parts, tr)
+	case query.kind == KindSet:
+		for _, member := range query.parts {
+			if d = lessOrEqualTo(member, rule, tr); !d.Matched() {
+				return d
+			}
+		}
+		return matched
+	case rule.kind == KindSet:
+		return nodeToSetCompare(query, rule.parts, tr)
+	case rule.kind == KindSExpression && query.kind == KindSExpression:
+		return sexpressionCompare(query, rule, tr)
+	case rule.kind == KindOctetString && query.kind == KindOctetString:
+		d = OctetStringCompare(query.octet, rule.octet)
+	case rule.kind == KindRange && query.kind == KindRange:
+		d = RangeCompare(query.rng, rule.rng)
+	case rule.kind == KindRange && query.kind == KindOctetString:
+		d = OctetToRangeCompare(query.octet, rule.rng)
+	case rule.kind == KindPrefix && query.kind == KindPrefix:
+		d = PrefixCompare(query.octet.Value, rule.octet.Value)
+	case rule.kind == KindPrefix && query.kind == KindOctetString:
+		d = OctetToPrefixCompare(query.octet, rule.octet.Value)
+	case rule.kind == KindSuffix && query.kind == KindSuffix:
+		d = SuffixCompare(query.octet.Value, rule.octet.Value)
+	case rule.kind == KindSuffix && query.kind == KindOctetString:
+		d = OctetToSuffixCompare(query.octet, rule.octet.Value)
+	default:
+		// values of different kinds do not compare
+		d = noMatch
+	}
+	tr.record(comparator(rule), query, rule, d)
+	return d
+}
+
+// CompareSequence compares the parts of two lists, the query must have at
+// least as many parts as the rule
+func CompareSequence(query, rule []Node) Decision {
+	return compareSequence(query, rule, nil)
+}
+
+func compareSequence(query, rule []Node, tr *tracer) Decision {
+	if len(query) < len(rule) {
+		tr.recordLength(len(query), len(rule))
+		return noMatch
+	}
+	for i, r := range rule {
+		if d := lessOrEqualTo(query[i], r, tr); !d.Matched() {
+			return d
+		}
+	}
+	return matched
+}
@@ -0,0 +1,339 @@
+package spocp
+
+import (
+	"errors"
+	"testing"
+)
+
+// conformance holds queries and rules after the examples of the SPOCP
+// S-expression specification, with whether the rule permits the query
+var conformance = []struct {
+	query, rule string
+	expected    bool
+}{
+	// atoms are equal or not
+	{"(fruit apple)", "(fruit apple)", true},
+	{"(fruit apple)", "(fruit pear)", false},
+	{"(fruit apple)", "(fruits apple)", false},
+	// a longer list is more specific than a shorter one
+	{"(fruit apple large red)", "(fruit apple)", true},
+	{"(fruit apple)", "(fruit apple large red)", false},
+	{"(fruit)", "(fruit)", true},
+	{"(fruit apple)", "(fruit)", true},
+	{"(fruit)", "(fruit apple)", false},
+	{"(http (page index.html) (action GET) (user olav))", "(http (page index.html) (action GET) (user))", true},
+	{"(http (page index.html) (action GET) (user))", "(http (page index.html) (action GET) (user olav))", false},
+	{"(http (page index.html) (action PUT) (user olav))", "(http (page index.html) (action GET) (user))", false},
+	// lists and atoms do not compare
+	{"(fruit apple)", "(fruit (apple))", false},
+	{"(fruit (apple))", "(fruit apple)", false},
+	// a set permits what any of its members permits
+	{"(fruit apple)", "(fruit (* set apple orange))", true},
+	{"(fruit lemon)", "(fruit (* set apple orange))", false},
+	{"(fruit (apple large))", "(fruit (* set (apple) orange))", true},
+	{"(fruit (pear large))", "(fruit (* set (apple) orange))", false},
+	// every member of a set in the query must be permitted
+	{"(fruit (* set apple orange))", "(fruit (* set apple orange lemon))", true},
+	{"(fruit (* set apple pear))", "(fruit (* set apple orange lemon))", false},
+	{"(fruit (* set apple))", "(fruit apple)", true},
+	{"(fruit (* set apple orange))", "(fruit apple)", false},
+	{"(a (* set (* set b c)))", "(a (* set b c))", true},
+	{"(a (* set d (* set b c)))", "(a (* set b c d))", true},
+	{"(a (* set (* set b e)))", "(a (* set b c))", false},
+	// ranges
+	{"(age 21)", "(age (* range numeric ge 18))", true},
+	{"(age 18)", "(age (* range numeric gt 18))", false},
+	{"(age 300)", "(age (* range numeric ge 18 lt 1000))", true},
+	{"(age 1000)", "(age (* range numeric ge 18 lt 1000))", false},
+	{"(age 12345678901234567890123)", "(age (* range numeric ge 18))", true},
+	{"(age old)", "(age (* range numeric ge 18))", false},
+	{"(at \"10:30:00\")", "(at (* range time ge \"08:00:00\" le \"17:00:00\"))", true},
+	{"(at \"18:00:00\")", "(at (* range time ge \"08:00:00\" le \"17:00:00\"))", false},
+	{"(on 2025-03-05T11:00:00+01:00)", "(on (* range date ge 2023-12-22T17:25:33+01:00))", true},
+	{"(from 130.239.1.100)", "(from (* range ipv4 ge 130.239.1.1 lt 130.239.1.127))", true},
+	{"(from 130.239.1.127)", "(from (* range ipv4 ge 130.239.1.1 lt 130.239.1.127))", false},
+	{"(from ::1)", "(from (* range ipv4 ge 0.0.0.0))", false},
+	{"(from \"2001:db8::1\")", "(from (* range ipv6 ge \"2001:db8::\" le \"2001:db8::ffff\"))", true},
+	{"(from \"2001:db9::1\")", "(from (* range ipv6 ge \"2001:db8::\" le \"2001:db8::ffff\"))", false},
+	// a range in the query must be within the range of the rule
+	{"(age (* range numeric ge 21 le 65))", "(age (* range numeric ge 18))", true},
+	{"(age (* range numeric ge 16 le 65))", "(age (* range numeric ge 18))", false},
+	{"(age (* range numeric ge 21))", "(age (* range numeric ge 18 lt 100))", false},
+	{"(age (* range numeric ge 21 lt 100))", "(age (* range numeric ge 18 le 100))", true},
+	{"(age (* range numeric ge 21 le 100))", "(age (* range numeric ge 18 lt 100))", false},
+	{"(age (* range numeric ge 18))", "(age (* range alpha ge 18))", false},
+	{"(name bob)", "(name (* range alpha ge abc le carol))", true},
+	{"(name abc)", "(name (* range alpha gt abc le carol))", false},
+	{"(name dave)", "(name (* range alpha ge abc le carol))", false},
+	{"(name Bob)", "(name (* range alpha ge abc le carol))", false},
+	{"(from (* range ipv4 ge 130.239.1.10 le 130.239.1.20))", "(from (* range ipv4 ge 130.239.1.1 lt 130.239.1.127))", true},
+	{"(age (* set 20 (* range numeric ge 30 le 40)))", "(age (* range numeric ge 18))", true},
+	// prefixes
+	{"(file /home/alice/notes)", "(file (* prefix /home/))", true},
+	{"(file /home/)", "(file (* prefix /home/))", true},
+	{"(file /etc/passwd)", "(file (* prefix /home/))", false},
+	{"(file /home)", "(file (* prefix /home/))", false},
+	{"(file (* prefix /home/alice/))", "(file (* prefix /home/))", true},
+	{"(file (* prefix /home/))", "(file (* prefix /home/alice/))", false},
+	{"(file (* prefix /home/))", "(file /home/)", false},
+	{"(file (* set /home/alice /home/bob))", "(file (* prefix /home/))", true},
+	// suffixes
+	{"(host www.example.org)", "(host (* suffix .example.org))", true},
+	{"(host .example.org)", "(host (* suffix .example.org))", true},
+	{"(host example.org)", "(host (* suffix .example.org))", false},
+	{"(host www.example.com)", "(host (* suffix .example.org))", false},
+	{"(host (* suffix .www.example.org))", "(host (* suffix .example.org))", true},
+	{"(host (* suffix .org))", "(host (* suffix .example.org))", false},
+	{"(host (* suffix .example.org))", "(host (* prefix www.))", false},
+	{"(host (* set www.example.org ftp.example.org))", "(host (* suffix .example.org))", true},
+}
+
+func TestConformance(t *testing.T) {
+	for _, c := range conformance {
+		query, err := Parse([]byte(c.query))
+		if err != nil {
+			t.Fatalf("%s: %v", c.query, err)
+		}
+		rule, err := Parse([]byte(c.rule))
+		if err != nil {
+			t.Fatalf("%s: %v", c.rule, err)
+		}
+		cmp, err := Match(query, rule)
+		if err != nil {
+			t.Errorf("%s against %s: %v", c.query, c.rule, err)
+		} else if cmp != c.expected {
+			t.Errorf("%s against %s: expected %v, got %v", c.query, c.rule, c.expected, cmp)
+		}
+	}
+}
+
+func TestDecision(t *testing.T) {
+	node, err := Parse([]byte("(fruit apple (level (* range numeric ge 10)))"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := Parse([]byte("(fruit pear)"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := other.Compare(*other); d != matched || !d.Matched() {
+		t.Errorf("expected a match, got %v", d)
+	}
+	if d := other.Compare(*node); d != noMatch || d.Err != nil {
+		t.Errorf("expected no match without an error, got %v", d)
+	}
+	if d := LessOrEqualTo(Node{}, *node); d.Outcome != OutcomeError || d.Err == nil {
+		t.Errorf("expected an error for the zero Node, got %v", d)
+	}
+	if _, err = Match(&Node{}, node); err == nil {
+		t.Error("expected Match to return the error")
+	}
+	// a broken part of a rule is reported even if a set has other members
+	set, err := SetOf(Atom([]byte("a")), Node{kind: KindRange, rng: &Range{valueType: "Unknown", boundary: [2]string{"ge"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := LessOrEqualTo(Atom([]byte("b")), set); d.Outcome != OutcomeError {
+		t.Errorf("expected an error, got %v", d)
+	}
+	if OutcomeNoMatch.String() != "no match" || failed(errors.New("broken")).String() != "error: broken" {
+		t.Error("unexpected outcome names")
+	}
+}
+
+func TestPrefixCompare(t *testing.T) {
+	var prefixes = []struct {
+		query    Node
+		rule     Node
+		expected bool
+	}{
+		{Atom([]byte("anything")), PrefixOf(nil), true},
+		{Atom(nil), PrefixOf(nil), true},
+		{Atom(nil), PrefixOf([]byte("a")), false},
+		{PrefixOf([]byte("a")), PrefixOf(nil), true},
+		{PrefixOf(nil), PrefixOf(nil), true},
+		{PrefixOf(nil), PrefixOf([]byte("a")), false},
+		{Atom([]byte{0x00, 0xff, 0x10}), PrefixOf([]byte{0x00, 0xff}), true},
+		{Atom([]byte{0x00, 0xfe, 0x10}), PrefixOf([]byte{0x00, 0xff}), false},
+		{PrefixOf([]byte{0x00, 0xff, 0x00}), PrefixOf([]byte{0x00, 0xff}), true},
+		{newOctetString(&OctetString{Value: []byte("/home/a"), Hint: []byte("text/plain")}), PrefixOf([]byte("/home/")), true},
+	}
+	for _, p := range prefixes {
+		if got := LessOrEqualTo(p.query, p.rule); got.Matched() != p.expected || got.Err != nil {
+			t.Errorf("%s against %s: expected %v, got %v", p.query, p.rule, p.expected, got)
+		}
+	}
+
+	query, err := Parse([]byte("(file /etc/passwd)"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule, err := Parse([]byte("(file (* prefix /home/))"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Explain(*query, *rule).String(); got != "file: /etc/passwd does not start with /home/" {
+		t.Errorf("unexpected explanation %q", got)
+	}
+}
+
+func TestSuffixCompare(t *testing.T) {
+	var suffixes = []struct {
+		query    Node
+		rule     Node
+		expected bool
+	}{
+		{Atom([]byte("anything")), SuffixOf(nil), true},
+		{Atom(nil), SuffixOf(nil), true},
+		{Atom(nil), SuffixOf([]byte("a")), false},
+		{SuffixOf([]byte("a")), SuffixOf(nil), true},
+		{SuffixOf(nil), SuffixOf(nil), true},
+		{SuffixOf(nil), SuffixOf([]byte("a")), false},
+		{Atom([]byte{0x10, 0x00, 0xff}), SuffixOf([]byte{0x00, 0xff}), true},
+		{Atom([]byte{0x10, 0x00, 0xfe}), SuffixOf([]byte{0x00, 0xff}), false},
+		{SuffixOf([]byte{0x00, 0x00, 0xff}), SuffixOf([]byte{0x00, 0xff}), true},
+		{Atom([]byte("ab")), SuffixOf([]byte("abc")), false},
+		{newOctetString(&OctetString{Value: []byte("a.txt"), Hint: []byte("text/plain")}), SuffixOf([]byte(".txt")), true},
+	}
+	for _, s := range suffixes {
+		if got := LessOrEqualTo(s.query, s.rule); got.Matched() != s.expected || got.Err != nil {
+			t.Errorf("%s against %s: expected %v, got %v", s.query, s.rule, s.expected, got)
+		}
+	}
+
+	query, err := Parse([]byte("(host www.example.com)"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule, err := Parse([]byte("(host (* suffix .example.org))"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Explain(*query, *rule).String(); got != "host: www.example.com does not end with .example.org" {
+		t.Errorf("unexpected explanation %q", got)
+	}
+}
+
+func TestRangeCompare(t *testing.T) {
+	var ranges = []struct {
+		query, rule string
+		expected    bool
+	}{
+		{"(* range numeric ge 10 le 20)", "(* range numeric ge 10 le 20)", true},
+		{"(* range numeric gt 10 lt 20)", "(* range numeric ge 10 le 20)", true},
+		{"(* range numeric ge 10)", "(* range numeric gt 10)", false},
+		{"(* range numeric gt 10)", "(* range numeric gt 10)", true},
+		{"(* range numeric le 20)", "(* range numeric lt 20)", false},
+		{"(* range numeric lt 20)", "(* range numeric lt 20)", true},
+		{"(* range numeric le 5)", "(* range numeric ge 10)", false},
+		{"(* range numeric gt 17)", "(* range numeric ge 18)", true},
+		{"(* range numeric ge 18)", "(* range numeric gt 17)", true},
+		{"(* range numeric gt 16)", "(* range numeric ge 18)", false},
+		{"(* range numeric lt 100)", "(* range numeric le 99)", true},
+		{"(* range numeric le 99)", "(* range numeric lt 100)", true},
+		{"(* range numeric le 100)", "(* range numeric lt 100)", false},
+		{"(* range numeric gt 99999)", "(* range numeric ge 100000)", true},
+		{"(* range numeric lt 1000)", "(* range numeric le 0999)", true},
+		{"(* range numeric lt 0)", "(* range numeric le 5)", true},
+		{"(* range numeric le 0)", "(* range numeric lt 0)", false},
+		{"(* range ipv4 gt 10.0.0.255)", "(* range ipv4 ge 10.0.1.0)", true},
+		{"(* range ipv4 ge 10.0.1.0)", "(* range ipv4 gt 10.0.0.255)", true},
+		{"(* range ipv4 lt 10.0.1.0)", "(* range ipv4 le 10.0.0.254)", false},
+		{"(* range ipv4 gt 255.255.255.255)", "(* range ipv4 gt 10.0.0.0)", true},
+		{"(* range ipv6 lt \"2001:db8::1:0\")", "(* range ipv6 le \"2001:db8::ffff\")", true},
+		{"(* range numeric le 12345678901234567890)", "(* range numeric lt 12345678901234567891)", true},
+		{"(* range alpha ge b le c)", "(* range alpha ge a le d)", true},
+		{"(* range alpha ge a le e)", "(* range alpha ge a le d)", false},
+		{"(* range date ge 2024-01-01T00:00:00Z)", "(* range date ge 2023-12-22T17:25:33+01:00)", true},
+		{"(* range date ge 2023-12-22T16:00:00Z)", "(* range date ge 2023-12-22T17:25:33+01:00)", false},
+		{"(* range time ge \"09:00:00\" le \"12:00:00\")", "(* range time ge \"08:00:00\" le \"17:00:00\")", true},
+		{"(* range time ge \"07:00:00\" le \"12:00:00\")", "(* range time ge \"08:00:00\" le \"17:00:00\")", false},
+		{"(* range ipv4 ge 10.0.0.0 le 10.0.0.255)", "(* range ipv4 ge 10.0.0.0 le 10.255.255.255)", true},
+		{"(* range ipv4 ge 10.0.0.0 le 11.0.0.0)", "(* range ipv4 ge 10.0.0.0 le 10.255.255.255)", false},
+		{"(* range ipv6 ge \"2001:db8::10\" lt \"2001:db8::20\")", "(* range ipv6 ge \"2001:db8::\" le \"2001:db8::ffff\")", true},
+		{"(* range ipv6 ge \"2001:db8::\")", "(* range ipv6 ge \"2001:db8::\" le \"2001:db8::ffff\")", false},
+		{"(* range ipv4 ge 10.0.0.0)", "(* range ipv6 ge \"::\")", false},
+	}
+	for _, r := range ranges {
+		query, err := Parse([]byte(r.query))
+		if err != nil {
+			t.Fatalf("%s: %v", r.query, err)
+		}
+		rule, err := Parse([]byte(r.rule))
+		if err != nil {
+			t.Fatalf("%s: %v", r.rule, err)
+		}
+		if got := RangeCompare(query.Range(), rule.Range()); got.Matched() != r.expected || got.Err != nil {
+			t.Errorf("%s against %s: expected %v, got %v", r.query, r.rule, r.expected, got)
+		}
+	}
+}
+
+func TestAlphaRangeCompare(t *testing.T) {
+	rule, err := Parse([]byte("(name (* range alpha ge b lt d))"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names = []struct {
+		name               string
+		bytewise, caseless bool
+	}{
+		{"b", true, true},
+		{"cz", true, true},
+		{"d", false, false},
+		{"a", false, false},
+		{"Bob", false, true},
+		{"Dave", false, false},
+		{"", false, false},
+		{"\xff", false, false},
+	}
+	limits := rule.Parts()[0].Range()
+	for _, collator := range []Collator{nil, CaseInsensitive} {
+		limits.SetCollator(collator)
+		for _, n := range names {
+			query, err := List("name", Atom([]byte(n.name)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := n.bytewise
+			if collator != nil {
+				expected = n.caseless
+			}
+			if got := LessOrEqualTo(query, *rule); got.Matched() != expected || got.Err != nil {
+				t.Errorf("%q with collator %v: expected %v, got %v", n.name, collator, expected, got)
+			}
+		}
+	}
+
+	query, err := Parse([]byte("(name (* range alpha ge Bob le Carol))"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	limits.SetCollator(nil)
+	if d := LessOrEqualTo(*query, *rule); d.Matched() {
+		t.Error("expected no match in byte order")
+	}
+	limits.SetCollator(CaseInsensitive)
+	if d := LessOrEqualTo(*query, *rule); !d.Matched() {
+		t.Errorf("expected a match ignoring case, got %v", d)
+	}
+	limits.SetCollator(nil)
+
+	p := Parser{Limits: DefaultLimits, Collator: CaseInsensitive}
+	caseless, err := p.Parse([]byte("(name (* range alpha ge b lt d))"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := LessOrEqualTo(*query, *caseless); !d.Matched() {
+		t.Errorf("expected the parser to set the collator, got %v", d)
+	}
+
+	query, err = Parse([]byte("(name dave)"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Explain(*query, *rule).String(); got != "name: dave not lt d" {
+		t.Errorf("unexpected explanation %q", got)
+	}
+}
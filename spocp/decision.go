@@ -0,0 +1,65 @@
+package spocp
+
+import (
+	"fmt"
+)
+
+// Outcome is the result of comparing a query with a rule
+type Outcome uint8
+
+const (
+	// OutcomeNoMatch is the Outcome of the zero Decision, the rule does not
+	// permit the query
+	OutcomeNoMatch Outcome = iota
+	// OutcomeMatch means the query is less than or equal to the rule
+	OutcomeMatch
+	// OutcomeError means the comparison could not be made, e.g. because a
+	// node is invalid
+	OutcomeError
+)
+
+var outcomeNames = [...]string{"no match", "match", "error"}
+
+func (o Outcome) String() string {
+	if int(o) < len(outcomeNames) {
+		return outcomeNames[o]
+	}
+	return fmt.Sprintf("Outcome(%d)", o)
+}
+
+// Decision is what a compare function decided, with the cause if it could
+// not decide
+type Decision struct {
+	Outcome Outcome
+	// Err is the cause of an OutcomeError decision
+	Err error
+}
+
+var (
+	matched = Decision{Outcome: OutcomeMatch}
+	noMatch = Decision{Outcome: OutcomeNoMatch}
+)
+
+// decide returns the decision for a comparison that cannot fail
+func decide(cmp bool) Decision {
+	if cmp {
+		return matched
+	}
+	return noMatch
+}
+
+func failed(err error) Decision {
+	return Decision{Outcome: OutcomeError, Err: err}
+}
+
+// Matched reports whether the rule permits the query
+func (d Decision) Matched() bool {
+	return d.Outcome == OutcomeMatch
+}
+
+func (d Decision) String() string {
+	if d.Outcome == OutcomeError {
+		return fmt.Sprintf("%s: %v", d.Outcome, d.Err)
+	}
+	return d.Outcome.String()
+}
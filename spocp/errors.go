@@ -0,0 +1,113 @@
+package spocp
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// snippetLength is the maximum number of input bytes quoted in a ParseError
+const snippetLength = 20
+
+// excerptWidth is the maximum number of input bytes shown in an excerpt
+const excerptWidth = 60
+
+// ParseError is returned by the parser when the input is not a well-formed
+// S-expression. Offset is the position in the input where the problem was
+// detected, Expected names the token the parser was looking for and Snippet
+// holds the input found at that position.
+// Path lists the tags of the lists enclosing the problem, star forms are
+// named by their type prefixed with '*', e.g. certificate/level/*range.
+// Excerpt shows the input around Offset on one line with a caret pointing
+// at Offset on the next.
+type ParseError struct {
+	Offset   int
+	Expected string
+	Snippet  string
+	Path     []string
+	Excerpt  string
+	Err      error
+}
+
+func (e *ParseError) Error() string {
+	msg := fmt.Sprintf("parse error at offset %d", e.Offset)
+	if len(e.Path) > 0 {
+		msg += " in " + strings.Join(e.Path, "/")
+	}
+	msg += ": expected " + e.Expected
+	if e.Snippet == "" {
+		msg += ", found end of input"
+	} else {
+		msg += fmt.Sprintf(", found %q", e.Snippet)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// newParseError builds a ParseError for position pos of the input
+func newParseError(inp *input, pos int, expected string, err error) *ParseError {
+	var snippet []byte
+
+	if pos < len(inp.bs) {
+		snippet = inp.bs[pos:]
+		if len(snippet) > snippetLength {
+			snippet = snippet[:snippetLength]
+		}
+	}
+	return &ParseError{
+		Offset:   pos,
+		Expected: expected,
+		Snippet:  string(snippet),
+		Path:     append([]string(nil), inp.path...),
+		Excerpt:  excerpt(inp.bs, pos),
+		Err:      err,
+	}
+}
+
+// relocate moves the error to position pos of src, used when the parsed
+// input was derived from src
+func (e *ParseError) relocate(src []byte, pos int) {
+	inp := input{bs: src}
+	moved := newParseError(&inp, pos, e.Expected, e.Err)
+	e.Offset = moved.Offset
+	e.Snippet = moved.Snippet
+	e.Excerpt = moved.Excerpt
+}
+
+// excerpt returns the line of src holding pos, shortened to excerptWidth
+// bytes, followed by a line with a caret under pos.
+func excerpt(src []byte, pos int) string {
+	if pos > len(src) {
+		pos = len(src)
+	}
+	begin := bytes.LastIndexByte(src[:pos], '\n') + 1
+	end := bytes.IndexByte(src[pos:], '\n')
+	if end == -1 {
+		end = len(src)
+	} else {
+		end += pos
+	}
+	if end-begin > excerptWidth {
+		if pos-begin > excerptWidth/2 {
+			begin = pos - excerptWidth/2
+		}
+		if end-begin > excerptWidth {
+			end = begin + excerptWidth
+		}
+	}
+	line := make([]byte, end-begin)
+	for i, b := range src[begin:end] {
+		// keep the caret aligned by showing one column per byte
+		if b < 32 || b > 126 {
+			b = '.'
+		}
+		line[i] = b
+	}
+	return string(line) + "\n" + strings.Repeat(" ", pos-begin) + "^"
+}
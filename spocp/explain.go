@@ -0,0 +1,159 @@
+package spocp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Step is one comparison made while deciding whether a rule permits a query
+type Step struct {
+	// Path holds the tags of the lists the comparison was made in, as in
+	// ParseError
+	Path []string
+	// Comparator is list, octet, set, range, prefix or suffix, after the
+	// rule value compared
+	Comparator string
+	Decision   Decision
+	// Reason tells why there was no match, e.g. 101 not le 100
+	Reason string
+}
+
+// String returns the path and the reason, certificate/level: 101 not le 100
+func (s Step) String() string {
+	reason := s.Reason
+	if reason == "" {
+		reason = s.Decision.String()
+	}
+	if len(s.Path) == 0 {
+		return reason
+	}
+	return strings.Join(s.Path, "/") + ": " + reason
+}
+
+// Explanation is a decision with the comparisons it was made from
+type Explanation struct {
+	Decision Decision
+	// Steps are the comparisons in the order they were made, values that
+	// were tried against the members of a set are left out
+	Steps []Step
+}
+
+// Explain decides like LessOrEqualTo and traces the comparisons made
+func Explain(query, rule Node) Explanation {
+	var tr tracer
+
+	d := lessOrEqualTo(query, rule, &tr)
+	return Explanation{Decision: d, Steps: tr.steps}
+}
+
+// Mismatch returns the step that decided against a match, nil if there was
+// a match
+func (e Explanation) Mismatch() *Step {
+	if e.Decision.Matched() || len(e.Steps) == 0 {
+		return nil
+	}
+	return &e.Steps[len(e.Steps)-1]
+}
+
+// String returns the decision, or the step that decided against a match
+func (e Explanation) String() string {
+	if step := e.Mismatch(); step != nil {
+		return step.String()
+	}
+	return e.Decision.String()
+}
+
+// tracer records the comparisons made, the methods do nothing on a nil
+// tracer
+type tracer struct {
+	path  []string
+	muted int
+	steps []Step
+}
+
+func (tr *tracer) enter(tag string) {
+	if tr != nil {
+		// copy, the path of a recorded step must not change
+		tr.path = append(tr.path[:len(tr.path):len(tr.path)], tag)
+	}
+}
+
+func (tr *tracer) leave() {
+	if tr != nil {
+		tr.path = tr.path[:len(tr.path)-1]
+	}
+}
+
+func (tr *tracer) mute() {
+	if tr != nil {
+		tr.muted++
+	}
+}
+
+func (tr *tracer) unmute() {
+	if tr != nil {
+		tr.muted--
+	}
+}
+
+func (tr *tracer) add(comparator string, d Decision, reason string) {
+	tr.steps = append(tr.steps, Step{Path: tr.path, Comparator: comparator, Decision: d, Reason: reason})
+}
+
+// record records the comparison of query with rule
+func (tr *tracer) record(comparator string, query, rule Node, d Decision) {
+	if tr == nil || tr.muted > 0 {
+		return
+	}
+	var reason string
+	if d.Outcome == OutcomeError {
+		reason = d.Err.Error()
+	} else if !d.Matched() {
+		reason = mismatch(comparator, query, rule)
+	}
+	tr.add(comparator, d, reason)
+}
+
+// recordLength records a query list with fewer parts than the rule list
+func (tr *tracer) recordLength(query, rule int) {
+	if tr == nil || tr.muted > 0 {
+		return
+	}
+	tr.add("list", noMatch, fmt.Sprintf("%d parts, the rule has %d", query, rule))
+}
+
+// comparator names the comparison of a value with rule
+func comparator(rule Node) string {
+	switch rule.kind {
+	case KindSExpression:
+		return "list"
+	case KindOctetString:
+		return "octet"
+	}
+	return rule.kind.String()
+}
+
+// mismatch returns why query is not less than or equal to rule
+func mismatch(comparator string, query, rule Node) string {
+	switch {
+	case comparator == "list" && query.kind == KindSExpression:
+		return fmt.Sprintf("tag %s not %s", newOctetString(query.octet), newOctetString(rule.octet))
+	case comparator == "set":
+		return fmt.Sprintf("%s not in %s", query, rule)
+	case comparator == "range" && query.kind == KindOctetString:
+		for num, boundary := range rule.rng.boundary {
+			if boundary == "" {
+				break
+			}
+			if !withinRangeLimit(query.octet, rule.rng, num).Matched() {
+				limit := appendAdvancedString(nil, rule.rng.rawLimit[num])
+				return fmt.Sprintf("%s not %s %s", query, boundary, limit)
+			}
+		}
+	case comparator == "prefix" && query.kind == KindOctetString:
+		return fmt.Sprintf("%s does not start with %s", query, appendAdvancedString(nil, rule.octet.Value))
+	case comparator == "suffix" && query.kind == KindOctetString:
+		return fmt.Sprintf("%s does not end with %s", query, appendAdvancedString(nil, rule.octet.Value))
+	}
+	return fmt.Sprintf("%s not %s", query, rule)
+}
@@ -0,0 +1,54 @@
+package spocp
+
+import (
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	rule, err := Parse([]byte("(certificate (issuer bob) (level (* range numeric ge 10 le 100)) (fruit (* set apple orange)))"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var queries = map[string]string{
+		"(certificate (issuer bob) (level 50) (fruit apple) (extra))": "match",
+		"(certificate (issuer bob) (level 101) (fruit apple))":        "certificate/level: 101 not le 100",
+		"(certificate (issuer bob) (level 5) (fruit apple))":          "certificate/level: 5 not ge 10",
+		"(certificate (issuer eve) (level 50) (fruit apple))":         "certificate/issuer: eve not bob",
+		"(certificate (issuer bob) (level 50) (fruit lemon))":         "certificate/fruit: lemon not in (* set apple orange)",
+		"(certificate (issuer bob) (level 50))":                       "certificate: 2 parts, the rule has 3",
+		"(certificate (issuer bob) (levels 50) (fruit apple))":        "certificate/levels: tag levels not level",
+		"(certificate (issuer (bob)) (level 50) (fruit apple))":       "certificate/issuer: (bob) not bob",
+		"(certificate issuer (level 50) (fruit apple))":               "certificate: issuer not (issuer bob)",
+		"(permit (issuer bob))":                                       "permit: tag permit not certificate",
+	}
+	for input, expected := range queries {
+		query, err := Parse([]byte(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		explanation := Explain(*query, *rule)
+		if got := explanation.String(); got != expected {
+			t.Errorf("%s: expected %q, got %q", input, expected, got)
+		}
+		if explanation.Decision != LessOrEqualTo(*query, *rule) {
+			t.Errorf("%s: expected the decision of LessOrEqualTo, got %v", input, explanation.Decision)
+		}
+	}
+
+	query, err := Parse([]byte("(certificate (issuer bob) (level 50) (fruit apple))"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	explanation := Explain(*query, *rule)
+	var comparators []string
+	for _, step := range explanation.Steps {
+		comparators = append(comparators, step.Comparator)
+	}
+	if len(comparators) != 3 || comparators[0] != "octet" || comparators[1] != "range" || comparators[2] != "set" {
+		t.Errorf("unexpected steps %v", explanation.Steps)
+	}
+	if explanation.Mismatch() != nil || explanation.Steps[1].String() != "certificate/level: match" {
+		t.Errorf("unexpected %v", explanation.Steps[1])
+	}
+}
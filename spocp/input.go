@@ -0,0 +1,38 @@
+package spocp
+
+// input is the byte buffer the parser works on together with the position
+// of the next byte to read.
+type input struct {
+	bs              []byte
+	currentPosition int
+	// path holds the tags of the lists enclosing bs
+	path   []string
+	limits Limits
+	// strict rejects anything but the canonical form
+	strict bool
+	// collator is set on the alpha ranges parsed
+	collator Collator
+}
+
+func (inp input) Remaining() int {
+	return len(inp.bs) - inp.currentPosition
+}
+func (inp input) NextByte() byte {
+	return inp.bs[inp.currentPosition]
+}
+func (inp input) Slice(begin, end int) []byte {
+	return inp.bs[begin:end]
+}
+func (inp input) Prefix(length int) []byte {
+	return inp.bs[inp.currentPosition : inp.currentPosition+length]
+}
+func (inp input) RemainingString() string {
+	return string(inp.bs[inp.currentPosition:])
+}
+func (inp input) RemainingBytes() []byte { return inp.bs[inp.currentPosition:] }
+
+// Enter records that the parser has moved into an element named tag
+func (inp *input) Enter(tag string) {
+	// force a copy so that sibling inputs do not share the backing array
+	inp.path = append(inp.path[:len(inp.path):len(inp.path)], tag)
+}
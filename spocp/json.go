@@ -0,0 +1,311 @@
+package spocp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// The JSON form of a Node is
+//
+//	list          ["certificate", ["issuer", "bob"]], the tag comes first
+//	octet string  "bob", or {"base64": "AAE="} if it is not valid UTF-8
+//	with a hint   {"value": "bob", "hint": "text/plain"}
+//	set           {"set": ["apple", "lemon"]}
+//	range         {"range": {"type": "numeric", "limits": [{"boundary": "ge", "value": "100"}]}}
+//	prefix        {"prefix": "ab"}
+//	suffix        {"suffix": "yz"}
+//
+// A hint, a prefix, a suffix and a range limit may also be given as
+// {"base64": ...}. The mapping is lossless, a Node converted to JSON and
+// back marshals to the same canonical form.
+
+// jsonOctet is the object form of an octet string
+type jsonOctet struct {
+	Value  *string         `json:"value,omitempty"`
+	Base64 []byte          `json:"base64,omitempty"`
+	Hint   json.RawMessage `json:"hint,omitempty"`
+}
+
+type jsonLimit struct {
+	Boundary string          `json:"boundary"`
+	Value    json.RawMessage `json:"value"`
+}
+
+type jsonRange struct {
+	Type   string      `json:"type"`
+	Limits []jsonLimit `json:"limits"`
+}
+
+// MarshalJSON returns the JSON form of the node
+func (nod Node) MarshalJSON() ([]byte, error) {
+	return appendJSON(nil, nod)
+}
+
+// UnmarshalJSON sets the node from its JSON form
+func (nod *Node) UnmarshalJSON(data []byte) error {
+	node, err := nodeFromJSON(data)
+	if err != nil {
+		return err
+	}
+	*nod = node
+	return nil
+}
+
+func appendJSON(dst []byte, node Node) ([]byte, error) {
+	var err error
+
+	switch node.kind {
+	case KindSExpression:
+		dst = append(dst, '[')
+		dst, err = appendOctetJSON(dst, node.octet)
+		if err != nil {
+			return nil, err
+		}
+		for _, part := range node.parts {
+			dst = append(dst, ',')
+			dst, err = appendJSON(dst, part)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return append(dst, ']'), nil
+	case KindOctetString:
+		return appendOctetJSON(dst, node.octet)
+	case KindSet:
+		dst = append(dst, `{"set":[`...)
+		for i, member := range node.parts {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst, err = appendJSON(dst, member)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return append(dst, "]}"...), nil
+	case KindRange:
+		rng := jsonRange{Type: string(RangeTypeName(node.rng.valueType))}
+		if rng.Type == "" {
+			return nil, fmt.Errorf("marshal json: unknown range type %q", node.rng.valueType)
+		}
+		for n, boundary := range node.rng.boundary {
+			if boundary == "" {
+				break
+			}
+			value, err := appendOctetJSON(nil, &OctetString{Value: node.rng.rawLimit[n]})
+			if err != nil {
+				return nil, err
+			}
+			rng.Limits = append(rng.Limits, jsonLimit{Boundary: boundary, Value: value})
+		}
+		return appendObjectJSON(dst, RangeStarform, rng)
+	case KindPrefix, KindSuffix:
+		value, err := appendOctetJSON(nil, node.octet)
+		if err != nil {
+			return nil, err
+		}
+		if node.kind == KindPrefix {
+			return appendObjectJSON(dst, PrefixStarform, json.RawMessage(value))
+		}
+		return appendObjectJSON(dst, SuffixStarform, json.RawMessage(value))
+	}
+	return nil, fmt.Errorf("marshal json: node has no value")
+}
+
+// appendObjectJSON appends an object with the single member key
+func appendObjectJSON(dst []byte, key string, value any) ([]byte, error) {
+	data, err := json.Marshal(map[string]any{key: value})
+	if err != nil {
+		return nil, err
+	}
+	return append(dst, data...), nil
+}
+
+func appendOctetJSON(dst []byte, oct *OctetString) ([]byte, error) {
+	var obj jsonOctet
+	var err error
+
+	if oct.Hint == nil && utf8.Valid(oct.Value) {
+		data, err := json.Marshal(string(oct.Value))
+		if err != nil {
+			return nil, err
+		}
+		return append(dst, data...), nil
+	}
+	if utf8.Valid(oct.Value) {
+		value := string(oct.Value)
+		obj.Value = &value
+	} else {
+		obj.Base64 = oct.Value
+	}
+	if oct.Hint != nil {
+		obj.Hint, err = appendOctetJSON(nil, &OctetString{Value: oct.Hint})
+		if err != nil {
+			return nil, err
+		}
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	return append(dst, data...), nil
+}
+
+func nodeFromJSON(data []byte) (Node, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return Node{}, fmt.Errorf("unmarshal json: no value")
+	}
+	switch data[0] {
+	case '[':
+		var elements []json.RawMessage
+		if err := json.Unmarshal(data, &elements); err != nil {
+			return Node{}, err
+		}
+		if len(elements) == 0 {
+			return Node{}, fmt.Errorf("unmarshal json: list without a tag")
+		}
+		tag, err := octetFromJSON(elements[0])
+		if err != nil {
+			return Node{}, err
+		}
+		if string(tag.Value) == "*" && tag.Hint == nil {
+			return Node{}, fmt.Errorf("unmarshal json: tag %q is reserved for star forms", tag.Value)
+		}
+		parts := make([]Node, 0, len(elements)-1)
+		for _, element := range elements[1:] {
+			part, err := nodeFromJSON(element)
+			if err != nil {
+				return Node{}, err
+			}
+			parts = append(parts, part)
+		}
+		return newSExpression(tag, parts), nil
+	case '{':
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return Node{}, err
+		}
+		if len(obj) != 1 {
+			break
+		}
+		if members, ok := obj[SetStarform]; ok {
+			return setFromJSON(members)
+		}
+		if rng, ok := obj[RangeStarform]; ok {
+			return rangeFromJSON(rng)
+		}
+		if value, ok := obj[PrefixStarform]; ok {
+			oct, err := plainOctetFromJSON(value)
+			if err != nil {
+				return Node{}, err
+			}
+			return newPrefix(oct.Value), nil
+		}
+		if value, ok := obj[SuffixStarform]; ok {
+			oct, err := plainOctetFromJSON(value)
+			if err != nil {
+				return Node{}, err
+			}
+			return newSuffix(oct.Value), nil
+		}
+	}
+	oct, err := octetFromJSON(data)
+	if err != nil {
+		return Node{}, err
+	}
+	return newOctetString(oct), nil
+}
+
+func setFromJSON(data []byte) (Node, error) {
+	var elements []json.RawMessage
+
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return Node{}, err
+	}
+	members := make([]Node, 0, len(elements))
+	for _, element := range elements {
+		member, err := nodeFromJSON(element)
+		if err != nil {
+			return Node{}, err
+		}
+		members = append(members, member)
+	}
+	return SetOf(members...)
+}
+
+func rangeFromJSON(data []byte) (Node, error) {
+	var rng jsonRange
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&rng); err != nil {
+		return Node{}, fmt.Errorf("unmarshal json: range: %w", err)
+	}
+	valueType := rangeValueType([]byte(rng.Type))
+	if valueType == "" {
+		return Node{}, fmt.Errorf("unmarshal json: unknown range type %q", rng.Type)
+	}
+	bounds := make([]Bound, 0, len(rng.Limits))
+	for _, limit := range rng.Limits {
+		value, err := plainOctetFromJSON(limit.Value)
+		if err != nil {
+			return Node{}, err
+		}
+		bounds = append(bounds, Bound{Boundary: limit.Boundary, Value: value.Value})
+	}
+	return RangeOf(valueType, bounds...)
+}
+
+// octetFromJSON reads an octet string, a JSON string or a jsonOctet
+func octetFromJSON(data []byte) (*OctetString, error) {
+	var oct OctetString
+	var obj jsonOctet
+
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var value string
+		if err := json.Unmarshal(data, &value); err != nil {
+			return nil, err
+		}
+		return &OctetString{Value: []byte(value)}, nil
+	}
+	if len(data) == 0 || data[0] != '{' {
+		return nil, fmt.Errorf("unmarshal json: expected a string or an object, found %.20q", data)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&obj); err != nil {
+		return nil, fmt.Errorf("unmarshal json: %w", err)
+	}
+	switch {
+	case obj.Value != nil && obj.Base64 == nil:
+		oct.Value = []byte(*obj.Value)
+	case obj.Value == nil && obj.Base64 != nil:
+		oct.Value = obj.Base64
+	default:
+		return nil, fmt.Errorf("unmarshal json: expected one of value or base64")
+	}
+	if obj.Hint != nil {
+		hint, err := plainOctetFromJSON(obj.Hint)
+		if err != nil {
+			return nil, err
+		}
+		oct.Hint = hint.Value
+	}
+	return &oct, nil
+}
+
+// plainOctetFromJSON reads an octet string that must not carry a hint
+func plainOctetFromJSON(data []byte) (*OctetString, error) {
+	oct, err := octetFromJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	if oct.Hint != nil {
+		return nil, fmt.Errorf("unmarshal json: unexpected hint")
+	}
+	return oct, nil
+}
@@ -0,0 +1,68 @@
+package spocp
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	var SExpressions = map[string]string{
+		"(11:certificate(6:issuer3:bob))":           `["certificate",["issuer","bob"]]`,
+		"(4:file[10:text/plain]5:hello)":            `["file",{"value":"hello","hint":"text/plain"}]`,
+		"(3:key2:\x00\xff)":                         `["key",{"base64":"AP8="}]`,
+		"(5:fruit(1:*3:set5:apple(5:lemon4:sour)))": `["fruit",{"set":["apple",["lemon","sour"]]}]`,
+		"(5:level(1:*5:range7:numeric2:ge2:102:lt3:100))": `["level",{"range":{"type":"numeric","limits":` +
+			`[{"boundary":"ge","value":"10"},{"boundary":"lt","value":"100"}]}}]`,
+		"(4:path(1:*6:prefix5:/home)(1:*6:suffix1:\xff))": `["path",{"prefix":"/home"},{"suffix":{"base64":"/w=="}}]`,
+		"(1:a0:[0:]0:)": `["a","",{"value":"","hint":""}]`,
+	}
+	for canonical, expected := range SExpressions {
+		node, err := Parse([]byte(canonical))
+		if err != nil {
+			t.Fatalf("%q: %v", canonical, err)
+		}
+		data, err := json.Marshal(node)
+		if err != nil {
+			t.Fatalf("%q: %v", canonical, err)
+		}
+		if string(data) != expected {
+			t.Errorf("expected %s, got %s", expected, data)
+		}
+
+		var decoded Node
+		if err = json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("%s: %v", data, err)
+		}
+		got, err := Marshal(&decoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != canonical {
+			t.Errorf("expected %q, got %q", canonical, got)
+		}
+	}
+}
+
+func TestJSONInvalid(t *testing.T) {
+	inputs := []string{
+		`[]`,
+		`["*", "set", "a"]`,
+		`[1]`,
+		`[{"value":"a","base64":"YQ=="}]`,
+		`["a",{"value":"b","colour":"red"}]`,
+		`["a",{"set":[]}]`,
+		`["a",{"set":["b","b"]}]`,
+		`["a",{"range":{"type":"numeric","limits":[{"boundary":"ge","value":"x"}]}}]`,
+		`["a",{"range":{"type":"octal","limits":[{"boundary":"ge","value":"7"}]}}]`,
+		`["a",{"range":{"type":"numeric"}}]`,
+		`["a",{"prefix":{"value":"b","hint":"text/plain"}}]`,
+		`["a",{"prefix":"b","suffix":"c"}]`,
+		`["a",{"value":"b","hint":{"value":"c","hint":"d"}}]`,
+	}
+	for _, input := range inputs {
+		var node Node
+		if err := json.Unmarshal([]byte(input), &node); err == nil {
+			t.Errorf("%s: expected an error", input)
+		}
+	}
+}
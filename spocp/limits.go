@@ -0,0 +1,78 @@
+package spocp
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInputTooLarge = errors.New("input too large")
+	ErrOctetTooLong  = errors.New("octet string too long")
+	ErrTooDeep       = errors.New("lists nested too deep")
+	ErrListTooWide   = errors.New("list has too many elements")
+	ErrSetTooLarge   = errors.New("set has too many members")
+)
+
+// Limits bounds the resources spent on parsing an S-expression, as needed
+// for queries from untrusted clients. A zero field means no limit.
+type Limits struct {
+	// MaxSize is the maximum size in bytes of the input
+	MaxSize int
+	// MaxOctetLength is the maximum length of an octet string
+	MaxOctetLength int
+	// MaxDepth is the maximum number of nested lists, the outermost list included
+	MaxDepth int
+	// MaxWidth is the maximum number of elements in a list, the tag excluded
+	MaxWidth int
+	// MaxSetSize is the maximum number of members of a set
+	MaxSetSize int
+}
+
+// DefaultLimits are the limits used by Parse, ParseAdvanced and NewDecoder
+var DefaultLimits = Limits{
+	MaxSize:        1 << 20,
+	MaxOctetLength: 1 << 16,
+	MaxDepth:       64,
+	MaxWidth:       1024,
+	MaxSetSize:     1024,
+}
+
+// checkSize verifies the size of the input
+func (lim Limits) checkSize(inp *input) error {
+	if lim.MaxSize > 0 && len(inp.bs) > lim.MaxSize {
+		return newParseError(inp, lim.MaxSize, fmt.Sprintf("input of at most %d bytes", lim.MaxSize), ErrInputTooLarge)
+	}
+	return nil
+}
+
+// checkOctetLength verifies the length n of an octet string found at pos
+func (lim Limits) checkOctetLength(inp *input, pos int, n int) error {
+	if lim.MaxOctetLength > 0 && n > lim.MaxOctetLength {
+		return newParseError(inp, pos, fmt.Sprintf("octet string of at most %d bytes", lim.MaxOctetLength), ErrOctetTooLong)
+	}
+	return nil
+}
+
+// checkDepth verifies the number of open brackets when a list starts at pos
+func (lim Limits) checkDepth(inp *input, pos int, brackets int) error {
+	if lim.MaxDepth > 0 && brackets > lim.MaxDepth {
+		return newParseError(inp, pos, fmt.Sprintf("at most %d nested lists", lim.MaxDepth), ErrTooDeep)
+	}
+	return nil
+}
+
+// checkWidth verifies the number of elements n of a list when the next starts at pos
+func (lim Limits) checkWidth(inp *input, pos int, n int) error {
+	if lim.MaxWidth > 0 && n > lim.MaxWidth {
+		return newParseError(inp, pos, fmt.Sprintf("at most %d list elements", lim.MaxWidth), ErrListTooWide)
+	}
+	return nil
+}
+
+// checkSetSize verifies the number of members n of a set when the next starts at pos
+func (lim Limits) checkSetSize(inp *input, pos int, n int) error {
+	if lim.MaxSetSize > 0 && n > lim.MaxSetSize {
+		return newParseError(inp, pos, fmt.Sprintf("at most %d set members", lim.MaxSetSize), ErrSetTooLarge)
+	}
+	return nil
+}
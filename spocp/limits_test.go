@@ -0,0 +1,92 @@
+package spocp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLimits(t *testing.T) {
+	var limits = Limits{
+		MaxSize:        100,
+		MaxOctetLength: 10,
+		MaxDepth:       3,
+		MaxWidth:       3,
+		MaxSetSize:     2,
+	}
+	var s_expressions = map[string]error{
+		"(1:a(1:b(1:c1:d)))":                     nil,
+		"(1:a(1:b(1:c(1:d))))":                   ErrTooDeep,
+		"(1:a(1:*3:set(1:b(1:c))))":              ErrTooDeep,
+		"(1:a1:b1:c1:d)":                         nil,
+		"(1:a1:b1:c1:d1:e)":                      ErrListTooWide,
+		"(1:a(1:*3:set1:b1:c))":                  nil,
+		"(1:a(1:*3:set1:b1:c1:d))":               ErrSetTooLarge,
+		"(1:a10:0123456789)":                     nil,
+		"(1:a11:0123456789a)":                    ErrOctetTooLong,
+		"(1:a[11:0123456789a]1:b)":               ErrOctetTooLong,
+		"(1:a" + strings.Repeat("1:b", 40) + ")": ErrInputTooLarge,
+		"(a b c d e)":                            ErrListTooWide,
+		"(a 0123456789a)":                        ErrOctetTooLong,
+		"(a " + strings.Repeat("b", 100) + ")":   ErrInputTooLarge,
+	}
+	p := Parser{Limits: limits}
+	for expression, expected := range s_expressions {
+		var parseErr *ParseError
+
+		_, err := p.Parse([]byte(expression))
+		if expected == nil {
+			if err != nil {
+				t.Errorf("%s: %v", expression, err)
+			}
+			continue
+		}
+		if !errors.Is(err, expected) || !errors.As(err, &parseErr) {
+			t.Errorf("%s: expected %v, got %v", expression, expected, err)
+		}
+	}
+}
+
+func TestLengthOverflow(t *testing.T) {
+	var s_expressions = []string{
+		"(1:a99999999999999999999999999:b)",
+		"(1:a9223372036854775808:b)",
+		"(1:a[9223372036854775808:b]1:c)",
+	}
+	p := Parser{}
+	for _, expression := range s_expressions {
+		var parseErr *ParseError
+
+		_, err := p.Parse([]byte(expression))
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%s: expected a ParseError, got %v", expression, err)
+		}
+	}
+}
+
+func TestDecoderLimits(t *testing.T) {
+	var streams = map[string]error{
+		"(1:a) (1:a" + strings.Repeat("1:b", 40) + ")": ErrInputTooLarge,
+		"(1:a) (1:a" + strings.Repeat("1:b", 40):       ErrInputTooLarge,
+		"(1:a) (1:a99999999999999999999:b)":            ErrOctetTooLong,
+		"(1:a) (1:a11:0123456789a)":                    ErrOctetTooLong,
+		"(1:a) (a 11\"0123456789a\")":                  ErrOctetTooLong,
+		"(1:a) (a 100000)":                             nil,
+		"(1:a) (1:a(1:b(1:c(1:d))))":                   ErrTooDeep,
+	}
+	for stream, expected := range streams {
+		dec := NewDecoder(strings.NewReader(stream))
+		dec.Parser.Limits = Limits{MaxSize: 100, MaxOctetLength: 10, MaxDepth: 3}
+		if _, err := dec.Decode(); err != nil {
+			t.Fatal(err)
+		}
+		_, err := dec.Decode()
+		if expected == nil {
+			if err != nil {
+				t.Errorf("%q: %v", stream, err)
+			}
+		} else if !errors.Is(err, expected) {
+			t.Errorf("%q: expected %v, got %v", stream, expected, err)
+		}
+	}
+}
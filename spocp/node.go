@@ -0,0 +1,199 @@
+package spocp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+)
+
+// Kind identifies the kind of value a Node holds
+type Kind uint8
+
+const (
+	// KindInvalid is the Kind of the zero Node
+	KindInvalid Kind = iota
+	// KindSExpression is a list, a tag followed by its parts
+	KindSExpression
+	KindOctetString
+	KindSet
+	KindRange
+	KindPrefix
+	KindSuffix
+)
+
+var kindNames = [...]string{"invalid", "sexpression", "octet_string", "set", "range", "prefix", "suffix"}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return fmt.Sprintf("Kind(%d)", k)
+}
+
+// Node is one value of an S-expression. Which accessors apply depends on
+// its Kind, accessors that do not apply return nil.
+type Node struct {
+	kind Kind
+	// octet is the tag of an s-expression, the value of an octet string
+	// or the value of a prefix or suffix
+	octet *OctetString
+	// parts are the parts of an s-expression or the members of a set
+	parts []Node
+	rng   *Range
+}
+
+func newSExpression(tag *OctetString, parts []Node) Node {
+	return Node{kind: KindSExpression, octet: tag, parts: parts}
+}
+
+func newOctetString(oct *OctetString) Node {
+	return Node{kind: KindOctetString, octet: oct}
+}
+
+func newSet(members []Node) Node {
+	return Node{kind: KindSet, parts: members}
+}
+
+func newRange(rng *Range) Node {
+	return Node{kind: KindRange, rng: rng}
+}
+
+func newPrefix(value []byte) Node {
+	return Node{kind: KindPrefix, octet: &OctetString{Value: value}}
+}
+
+func newSuffix(value []byte) Node {
+	return Node{kind: KindSuffix, octet: &OctetString{Value: value}}
+}
+
+func (nod Node) Kind() Kind {
+	return nod.kind
+}
+
+// Tag returns the tag of an s-expression
+func (nod Node) Tag() *OctetString {
+	if nod.kind != KindSExpression {
+		return nil
+	}
+	return nod.octet
+}
+
+// Parts returns the elements following the tag of an s-expression
+func (nod Node) Parts() []Node {
+	if nod.kind != KindSExpression {
+		return nil
+	}
+	return nod.parts
+}
+
+// Octet returns the value of an octet string
+func (nod Node) Octet() *OctetString {
+	if nod.kind != KindOctetString {
+		return nil
+	}
+	return nod.octet
+}
+
+// Members returns the members of a set
+func (nod Node) Members() []Node {
+	if nod.kind != KindSet {
+		return nil
+	}
+	return nod.parts
+}
+
+func (nod Node) Range() *Range {
+	if nod.kind != KindRange {
+		return nil
+	}
+	return nod.rng
+}
+
+func (nod Node) Prefix() []byte {
+	if nod.kind != KindPrefix {
+		return nil
+	}
+	return nod.octet.Value
+}
+
+func (nod Node) Suffix() []byte {
+	if nod.kind != KindSuffix {
+		return nil
+	}
+	return nod.octet.Value
+}
+
+// Equal reports whether both nodes have the same canonical form. The order
+// of set members matters.
+func (nod Node) Equal(other Node) bool {
+	if nod.kind != other.kind || len(nod.parts) != len(other.parts) {
+		return false
+	}
+	if nod.octet != nil && !equalOctetString(nod.octet, other.octet) {
+		return false
+	}
+	if nod.rng != nil && !equalRange(nod.rng, other.rng) {
+		return false
+	}
+	for i := range nod.parts {
+		if !nod.parts[i].Equal(other.parts[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalOctetString(a, b *OctetString) bool {
+	// a missing hint differs from an empty one, [0:]
+	return bytes.Equal(a.Value, b.Value) && bytes.Equal(a.Hint, b.Hint) && (a.Hint == nil) == (b.Hint == nil)
+}
+
+func equalRange(a, b *Range) bool {
+	return a.valueType == b.valueType && a.boundary == b.boundary &&
+		bytes.Equal(a.rawLimit[0], b.rawLimit[0]) && bytes.Equal(a.rawLimit[1], b.rawLimit[1])
+}
+
+// Clone returns a deep copy of the node. A parsed node shares its strings
+// with the input, a clone does not.
+func (nod Node) Clone() Node {
+	clone := Node{kind: nod.kind}
+	if nod.octet != nil {
+		clone.octet = &OctetString{Value: cloneBytes(nod.octet.Value), Hint: cloneBytes(nod.octet.Hint)}
+	}
+	if nod.rng != nil {
+		rng := *nod.rng
+		for n := range rng.rawLimit {
+			rng.rawLimit[n] = cloneBytes(rng.rawLimit[n])
+		}
+		clone.rng = &rng
+	}
+	if nod.parts != nil {
+		clone.parts = make([]Node, len(nod.parts))
+		for i, part := range nod.parts {
+			clone.parts[i] = part.Clone()
+		}
+	}
+	return clone
+}
+
+// cloneBytes copies b, keeping the difference between nil and empty
+func cloneBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+// Hash returns the SHA-256 digest of the canonical form, the way SPOCP
+// servers identify rules. Equal nodes have the same hash.
+func (nod Node) Hash() [sha256.Size]byte {
+	// only the zero Node has no canonical form, it hashes as empty input
+	data, _ := appendNode(nil, nod)
+	return sha256.Sum256(data)
+}
+
+// Compare decides whether the node, as a query, is permitted by rule, see
+// LessOrEqualTo
+func (nod Node) Compare(rule Node) Decision {
+	return LessOrEqualTo(nod, rule)
+}
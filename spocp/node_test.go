@@ -0,0 +1,96 @@
+package spocp
+
+import (
+	"testing"
+)
+
+func TestNodeKind(t *testing.T) {
+	node, err := Parse([]byte("(11:certificate3:bob(1:*3:set1:a1:b)(1:*5:range7:numeric2:ge1:5)(1:*6:prefix2:ab)(1:*6:suffix2:yz))"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.Kind() != KindSExpression || string(node.Tag().Value) != "certificate" {
+		t.Fatalf("expected the certificate s-expression, got %v", node.Kind())
+	}
+	if node.Octet() != nil || node.Members() != nil || node.Range() != nil {
+		t.Error("accessors of other kinds should return nil")
+	}
+
+	parts := node.Parts()
+	expected := []Kind{KindOctetString, KindSet, KindRange, KindPrefix, KindSuffix}
+	if len(parts) != len(expected) {
+		t.Fatalf("expected %d parts, got %d", len(expected), len(parts))
+	}
+	for i, kind := range expected {
+		if parts[i].Kind() != kind {
+			t.Errorf("part %d: expected %v, got %v", i, kind, parts[i].Kind())
+		}
+	}
+	if string(parts[0].Octet().Value) != "bob" || parts[0].Tag() != nil {
+		t.Error("unexpected octet string accessors")
+	}
+	if len(parts[1].Members()) != 2 || parts[1].Parts() != nil {
+		t.Error("unexpected set accessors")
+	}
+	if parts[2].Range().valueType != NUMERIC {
+		t.Errorf("expected a numeric range, got %s", parts[2].Range().valueType)
+	}
+	if string(parts[3].Prefix()) != "ab" || parts[3].Suffix() != nil {
+		t.Error("unexpected prefix accessors")
+	}
+	if string(parts[4].Suffix()) != "yz" || parts[4].Prefix() != nil {
+		t.Error("unexpected suffix accessors")
+	}
+	if (Node{}).Kind() != KindInvalid || KindOctetString.String() != "octet_string" {
+		t.Error("unexpected zero Node kind or name")
+	}
+}
+
+func TestEqualCloneHash(t *testing.T) {
+	var SExpressions = []string{
+		"(11:certificate(6:issuer3:bob)(5:level(1:*5:range7:numeric2:ge3:1002:lt4:1000)))",
+		"(11:certificate(6:issuer3:bob)(5:level(1:*5:range7:numeric2:ge3:1002:le4:1000)))",
+		"(11:certificate(6:issuer3:bob)(5:level(1:*5:range7:numeric2:ge3:100)))",
+		"(11:certificate(6:issuer3:bob))",
+		"(11:certificate(6:issuer5:alice))",
+		"([10:text/plain]11:certificate(6:issuer3:bob))",
+		"(1:a1:b)",
+		"(1:a[0:]1:b)",
+		"(1:a(1:*3:set1:b1:c))",
+		"(1:a(1:*3:set1:c1:b))",
+		"(1:a(1:*6:prefix1:b))",
+		"(1:a(1:*6:suffix1:b))",
+		"(1:a(1:b))",
+	}
+	nodes := make([]*Node, len(SExpressions))
+	for i, canonical := range SExpressions {
+		node, err := Parse([]byte(canonical))
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes[i] = node
+	}
+	for i, a := range nodes {
+		for j, b := range nodes {
+			if a.Equal(*b) != (i == j) {
+				t.Errorf("%s equal to %s: expected %v", SExpressions[i], SExpressions[j], i == j)
+			}
+			if (a.Hash() == b.Hash()) != (i == j) {
+				t.Errorf("%s hash equal to %s: expected %v", SExpressions[i], SExpressions[j], i == j)
+			}
+		}
+	}
+
+	input := []byte(SExpressions[0])
+	node, err := Parse(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone := node.Clone()
+	hash := node.Hash()
+	// the parsed node shares its strings with the input, the clone does not
+	copy(input, "(11:certificate(6:issuer3:eve)")
+	if node.Equal(clone) || clone.Hash() != hash || !clone.Equal(*nodes[0]) {
+		t.Errorf("expected the clone to keep the original value, got %s", clone)
+	}
+}
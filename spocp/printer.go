@@ -0,0 +1,221 @@
+package spocp
+
+import (
+	"encoding/base64"
+	"io"
+	"unicode/utf8"
+)
+
+// ANSI escapes used by a Printer with Color set
+const (
+	colorReset = "\x1b[0m"
+	colorTag   = "\x1b[1;34m"
+	colorStar  = "\x1b[35m"
+	colorHint  = "\x1b[33m"
+)
+
+// Printer writes nodes in the advanced form, indenting lists that do not
+// fit on one line:
+//
+//	(certificate
+//	    (issuer bob)
+//	    (level (* range numeric ge 100)))
+type Printer struct {
+	// Width is the line width lists are fitted into, 0 writes every node
+	// on one line
+	Width int
+	// Indent is written once per nesting level at the start of a line
+	Indent string
+	// Color highlights tags, star forms and display hints with ANSI escapes
+	Color bool
+}
+
+// DefaultPrinter fits lists into 80 columns, indenting by four spaces
+var DefaultPrinter = Printer{Width: 80, Indent: string(TAB)}
+
+// String returns the advanced form of the node on one line
+func (nod Node) String() string {
+	var p Printer
+	return p.Sprint(&nod)
+}
+
+// Sprint returns the advanced form of node
+func (p *Printer) Sprint(node *Node) string {
+	return string(p.appendNode(nil, *node, 0))
+}
+
+// Fprint writes the advanced form of node to w, without a final newline
+func (p *Printer) Fprint(w io.Writer, node *Node) error {
+	_, err := w.Write(p.appendNode(nil, *node, 0))
+	return err
+}
+
+// appendNode appends node, starting at the given nesting level
+func (p *Printer) appendNode(dst []byte, node Node, level int) []byte {
+	var plain Printer
+
+	if node.kind != KindSExpression && node.kind != KindSet {
+		return p.appendFlat(dst, node)
+	}
+	// the width is measured without color escapes
+	if p.Width <= 0 || level*len(p.Indent)+len(plain.appendFlat(nil, node)) <= p.Width {
+		return p.appendFlat(dst, node)
+	}
+	dst = append(dst, LeftBracket)
+	if node.kind == KindSExpression {
+		dst = p.appendOctetString(dst, node.octet, colorTag)
+	} else {
+		dst = p.appendStarForm(dst, SetStarform)
+	}
+	for _, part := range node.parts {
+		dst = append(dst, '\n')
+		for i := 0; i <= level; i++ {
+			dst = append(dst, p.Indent...)
+		}
+		dst = p.appendNode(dst, part, level+1)
+	}
+	return append(dst, RightBracket)
+}
+
+// appendFlat appends node on one line
+func (p *Printer) appendFlat(dst []byte, node Node) []byte {
+	switch node.kind {
+	case KindSExpression:
+		dst = append(dst, LeftBracket)
+		dst = p.appendOctetString(dst, node.octet, colorTag)
+		for _, part := range node.parts {
+			dst = append(dst, ' ')
+			dst = p.appendFlat(dst, part)
+		}
+		return append(dst, RightBracket)
+	case KindOctetString:
+		return p.appendOctetString(dst, node.octet, "")
+	case KindSet:
+		dst = append(dst, LeftBracket)
+		dst = p.appendStarForm(dst, SetStarform)
+		for _, member := range node.parts {
+			dst = append(dst, ' ')
+			dst = p.appendFlat(dst, member)
+		}
+		return append(dst, RightBracket)
+	case KindRange:
+		dst = append(dst, LeftBracket)
+		dst = p.appendStarForm(dst, RangeStarform)
+		dst = append(dst, ' ')
+		dst = p.appendColored(dst, RangeTypeName(node.rng.valueType), colorStar)
+		for n, boundary := range node.rng.boundary {
+			if boundary == "" {
+				break
+			}
+			dst = append(dst, ' ')
+			dst = p.appendColored(dst, []byte(boundary), colorStar)
+			dst = append(dst, ' ')
+			dst = appendAdvancedString(dst, node.rng.rawLimit[n])
+		}
+		return append(dst, RightBracket)
+	case KindPrefix, KindSuffix:
+		dst = append(dst, LeftBracket)
+		if node.kind == KindPrefix {
+			dst = p.appendStarForm(dst, PrefixStarform)
+		} else {
+			dst = p.appendStarForm(dst, SuffixStarform)
+		}
+		dst = append(dst, ' ')
+		dst = appendAdvancedString(dst, node.octet.Value)
+		return append(dst, RightBracket)
+	}
+	return append(dst, "<invalid>"...)
+}
+
+// appendStarForm appends the star and the type of a star form
+func (p *Printer) appendStarForm(dst []byte, starForm string) []byte {
+	dst = p.appendColored(dst, Star, colorStar)
+	dst = append(dst, ' ')
+	return p.appendColored(dst, []byte(starForm), colorStar)
+}
+
+// appendOctetString appends oct preceded by its display hint, the value in
+// the given color
+func (p *Printer) appendOctetString(dst []byte, oct *OctetString, color string) []byte {
+	if oct.Hint != nil {
+		if p.Color {
+			dst = append(dst, colorHint...)
+		}
+		dst = append(dst, LeftSquareBracket)
+		dst = appendAdvancedString(dst, oct.Hint)
+		dst = append(dst, RightSquareBracket)
+		if p.Color {
+			dst = append(dst, colorReset...)
+		}
+	}
+	return p.appendColored(dst, oct.Value, color)
+}
+
+func (p *Printer) appendColored(dst []byte, value []byte, color string) []byte {
+	if !p.Color || color == "" {
+		return appendAdvancedString(dst, value)
+	}
+	dst = append(dst, color...)
+	dst = appendAdvancedString(dst, value)
+	return append(dst, colorReset...)
+}
+
+// appendAdvancedString appends value as a token if possible, as a quoted
+// string if it is text and in base64 otherwise
+func appendAdvancedString(dst []byte, value []byte) []byte {
+	if isToken(value) {
+		return append(dst, value...)
+	}
+	if !isText(value) {
+		dst = append(dst, '|')
+		dst = base64.StdEncoding.AppendEncode(dst, value)
+		return append(dst, '|')
+	}
+	dst = append(dst, '"')
+	for _, c := range value {
+		switch c {
+		case '"', '\\':
+			dst = append(dst, '\\', c)
+		case '\n':
+			dst = append(dst, '\\', 'n')
+		case '\t':
+			dst = append(dst, '\\', 't')
+		case '\r':
+			dst = append(dst, '\\', 'r')
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return append(dst, '"')
+}
+
+// isText reports whether value is UTF-8 without control characters other
+// than tabs and line breaks
+func isText(value []byte) bool {
+	for _, c := range value {
+		if (c < ' ' && c != '\t' && c != '\n' && c != '\r') || c == 0x7f {
+			return false
+		}
+	}
+	return utf8.Valid(value)
+}
+
+// isToken reports whether value reads back as the same string when written
+// as a token. A token starting with digits followed by ':' would be read as
+// a verbatim string.
+func isToken(value []byte) bool {
+	if len(value) == 0 {
+		return false
+	}
+	lengthPrefix := true
+	for _, c := range value {
+		if !isTokenChar(c) {
+			return false
+		}
+		if lengthPrefix && c == ':' {
+			return false
+		}
+		lengthPrefix = lengthPrefix && digit(c)
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package spocp
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPrinter(t *testing.T) {
+	var SExpressions = []string{
+		"(11:certificate(6:issuer3:bob)(5:level(1:*5:range7:numeric2:ge3:1002:lt4:1000)))",
+		"(4:when(1:*5:range4:time2:ge8:10:30:00))",
+		"(5:fruit(1:*3:set5:apple(5:lemon(4:sour))(1:*3:set1:a1:b)))",
+		"(4:path(1:*6:prefix6:/home/)(1:*6:suffix4:.txt)(1:*6:prefix0:))",
+		"(4:file[10:text/plain]5:hello[3:a b]2:\x00\x01)",
+		"(4:name11:Alice Smith4:\"\\\n\t0:3:1:2)",
+		strings.Repeat("(1:a", 20) + strings.Repeat(")", 20),
+		"(2:106:apples(3:1003:xyz)([2:4k]2:424:half))",
+	}
+	printers := []Printer{{}, DefaultPrinter, {Width: 20, Indent: "\t"}}
+	for _, canonical := range SExpressions {
+		node, err := Parse([]byte(canonical))
+		if err != nil {
+			t.Fatalf("%q: %v", canonical, err)
+		}
+		if parsed, err := Parse([]byte(node.String())); err != nil {
+			t.Errorf("%s: %v", node, err)
+		} else if got, _ := Marshal(parsed); string(got) != canonical {
+			t.Errorf("%s: expected %q, got %q", node, canonical, got)
+		}
+		for _, printer := range printers {
+			var buf bytes.Buffer
+			if err = printer.Fprint(&buf, node); err != nil {
+				t.Fatal(err)
+			}
+			if printer.Width == 0 && strings.Contains(buf.String(), "\n") {
+				t.Errorf("expected a single line, got %s", buf.String())
+			}
+			parsed, err := Parse(buf.Bytes())
+			if err != nil {
+				t.Fatalf("%s: %v", buf.String(), err)
+			}
+			got, err := Marshal(parsed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != canonical {
+				t.Errorf("%s: expected %q, got %q", buf.String(), canonical, got)
+			}
+		}
+	}
+}
+
+func TestPrinterLayout(t *testing.T) {
+	node, err := Parse([]byte("(certificate (issuer bob) (subject (name \"Alice Smith\") (dept engineering)) " +
+		"(level (* range numeric ge 100)) (fruit (* set apple orange lemon)))"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "(certificate\n" +
+		"  (issuer bob)\n" +
+		"  (subject\n" +
+		"    (name \"Alice Smith\")\n" +
+		"    (dept engineering))\n" +
+		"  (level\n" +
+		"    (* range numeric ge 100))\n" +
+		"  (fruit\n" +
+		"    (* set\n" +
+		"      apple\n" +
+		"      orange\n" +
+		"      lemon)))"
+	printer := Printer{Width: 28, Indent: "  "}
+	if got := printer.Sprint(node); got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+
+	printer.Color = true
+	if got := printer.Sprint(node); !strings.Contains(got, colorTag+"certificate"+colorReset) {
+		t.Errorf("expected a colored tag, got %q", got)
+	}
+	if got := node.String(); strings.Contains(got, "\n") || !strings.HasPrefix(got, "(certificate (issuer bob)") {
+		t.Errorf("unexpected %s", got)
+	}
+}
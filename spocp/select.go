@@ -0,0 +1,168 @@
+package spocp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Selector picks parts out of S-expressions. It is a list of steps
+// separated by '/', each step selecting among the parts of what the
+// previous step selected, the first step among the S-expression itself:
+//
+//	name     lists with the tag name
+//	3        the third part of a list or member of a set, counting from 1
+//	*        every part
+//	**       every part at any depth below, or the parts themselves
+//	*set     sets, likewise *range, *prefix and *suffix
+//
+// certificate/subject/1 selects alice in
+// (certificate (subject alice)) and certificate/**/level selects every
+// level list within a certificate.
+type Selector struct {
+	steps []step
+}
+
+type step struct {
+	// descend is set for **
+	descend bool
+	// any is set for *
+	any   bool
+	kind  Kind
+	tag   string
+	index int
+}
+
+// CompileSelector parses a selector
+func CompileSelector(selector string) (*Selector, error) {
+	var sel Selector
+
+	if selector == "" {
+		return nil, fmt.Errorf("selector: empty")
+	}
+	for _, text := range strings.Split(selector, "/") {
+		var st step
+		switch text {
+		case "":
+			return nil, fmt.Errorf("selector %q: empty step", selector)
+		case "**":
+			st.descend = true
+		case "*":
+			st.any = true
+		case "*" + SetStarform:
+			st.kind = KindSet
+		case "*" + RangeStarform:
+			st.kind = KindRange
+		case "*" + PrefixStarform:
+			st.kind = KindPrefix
+		case "*" + SuffixStarform:
+			st.kind = KindSuffix
+		default:
+			if digit(text[0]) {
+				index, err := strconv.Atoi(text)
+				if err != nil || index < 1 {
+					return nil, fmt.Errorf("selector %q: invalid index %q", selector, text)
+				}
+				st.index = index
+			} else if text[0] == '*' {
+				return nil, fmt.Errorf("selector %q: unknown star form %q", selector, text)
+			} else {
+				st.kind = KindSExpression
+				st.tag = text
+			}
+		}
+		sel.steps = append(sel.steps, st)
+	}
+	return &sel, nil
+}
+
+// Select returns the nodes the selector picks out of node, in the order
+// they appear
+func Select(node *Node, selector string) ([]Node, error) {
+	sel, err := CompileSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	return sel.Select(node), nil
+}
+
+// Select returns the nodes the selector picks out of node, in the order
+// they appear
+func (sel *Selector) Select(node *Node) []Node {
+	// the first step selects among the parts of a parent holding node
+	top := &Node{kind: KindSet, parts: []Node{*node}}
+	current := []*Node{top}
+	for n, st := range sel.steps {
+		var next []*Node
+		if st.descend {
+			seen := make(map[*Node]bool)
+			for _, parent := range current {
+				next = descendants(next, parent, seen)
+			}
+			if n < len(sel.steps)-1 {
+				// the nodes themselves are parents of the next step too
+				next = append(current, next...)
+			}
+		} else {
+			for _, parent := range current {
+				next = st.appendMatches(next, parent)
+			}
+		}
+		current = next
+	}
+
+	// a node may have been reached more than once and not in order
+	selected := make(map[*Node]bool, len(current))
+	for _, node := range current {
+		selected[node] = true
+	}
+	result := make([]Node, 0, len(selected))
+	for _, node := range descendants(nil, top, make(map[*Node]bool)) {
+		if selected[node] {
+			result = append(result, *node)
+		}
+	}
+	return result
+}
+
+// appendMatches appends the parts of parent matching the step
+func (st step) appendMatches(dst []*Node, parent *Node) []*Node {
+	if parent.kind != KindSExpression && parent.kind != KindSet {
+		return dst
+	}
+	if st.index > 0 {
+		if st.index <= len(parent.parts) {
+			dst = append(dst, &parent.parts[st.index-1])
+		}
+		return dst
+	}
+	for i := range parent.parts {
+		part := &parent.parts[i]
+		switch {
+		case st.any:
+		case part.kind != st.kind:
+			continue
+		case st.kind == KindSExpression && string(part.octet.Value) != st.tag:
+			continue
+		}
+		dst = append(dst, part)
+	}
+	return dst
+}
+
+// descendants appends all the nodes below parent not seen before
+func descendants(dst []*Node, parent *Node, seen map[*Node]bool) []*Node {
+	if parent.kind != KindSExpression && parent.kind != KindSet {
+		return dst
+	}
+	for i := range parent.parts {
+		part := &parent.parts[i]
+		if seen[part] {
+			continue
+		}
+		seen[part] = true
+		dst = append(dst, part)
+		dst = descendants(dst, part, seen)
+	}
+	return dst
+}
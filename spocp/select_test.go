@@ -0,0 +1,57 @@
+package spocp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelect(t *testing.T) {
+	node, err := Parse([]byte("(certificate (issuer bob) (subject alice (level 2)) (level 1) " +
+		"(fruit (* set apple (level 3))) (when (* range numeric ge 10)) (path (* prefix /home)))"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var selectors = map[string]string{
+		"certificate/subject/1":     "alice",
+		"certificate/subject/2":     "(level 2)",
+		"certificate/subject/3":     "",
+		"certificate/issuer":        "(issuer bob)",
+		"certificate/*/level":       "(level 2)",
+		"certificate/level/1":       "1",
+		"certificate/**/level":      "(level 2) (level 1) (level 3)",
+		"**/level/1":                "2 1 3",
+		"certificate/fruit/*set/*":  "apple (level 3)",
+		"certificate/*/*range":      "(* range numeric ge 10)",
+		"certificate/**/*prefix":    "(* prefix /home)",
+		"*":                         node.String(),
+		"subject":                   "",
+		"certificate/**":            "",
+		"certificate/**/**/level/1": "2 1 3",
+	}
+	for selector, expected := range selectors {
+		nodes, err := Select(node, selector)
+		if err != nil {
+			t.Fatalf("%s: %v", selector, err)
+		}
+		var got []string
+		for _, n := range nodes {
+			got = append(got, n.String())
+		}
+		if selector == "certificate/**" {
+			if len(got) != 17 {
+				t.Errorf("%s: expected 17 nodes, got %d", selector, len(got))
+			}
+			continue
+		}
+		if strings.Join(got, " ") != expected {
+			t.Errorf("%s: expected %s, got %s", selector, expected, strings.Join(got, " "))
+		}
+	}
+
+	for _, selector := range []string{"", "certificate//level", "certificate/0", "certificate/*list", "a/99999999999999999999"} {
+		if _, err := CompileSelector(selector); err == nil {
+			t.Errorf("%q: expected an error", selector)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package spocp
+
+import (
+	"fmt"
+	"net/netip"
+	"time"
+)
+
+var LeftBracket byte = 40
+var RightBracket byte = 41
+var LeftSquareBracket byte = 91
+var RightSquareBracket byte = 93
+
+var TAB = []byte{32, 32, 32, 32}
+
+type OctetString struct {
+	Value []byte
+	// Hint is the display hint, e.g. text/plain, nil if there is none
+	Hint []byte
+}
+
+type Range struct {
+	valueType string
+	boundary  [2]string
+	// rawLimit holds the limits as they appeared in the input
+	rawLimit  [2][]byte
+	ipv4Limit [2]netip.Addr
+	dateLimit [2]time.Time
+	timeLimit [2]time.Time
+	ipv6Limit [2]netip.Addr
+	// collator orders the values of an alpha range, nil for byte order
+	collator Collator
+}
+
+const (
+	SetStarform    = "set"
+	RangeStarform  = "range"
+	PrefixStarform = "prefix"
+	SuffixStarform = "suffix"
+)
+
+func digit(c byte) bool {
+	if c >= 48 && c <= 57 {
+		return true
+	} else {
+		return false
+	}
+}
+
+func getLen(inp *input) (int, int, error) {
+	n := 0
+	b := -1
+
+	remainder := inp.Remaining()
+	if remainder == 0 || !digit(inp.NextByte()) {
+		return -1, b, newParseError(inp, inp.currentPosition, "length", nil)
+	}
+	for i, val := range inp.bs[inp.currentPosition:] {
+		if digit(val) {
+			if n != 0 {
+				n *= 10
+			}
+			n += int(val) - 48 // '0' ascii
+			if err := inp.limits.checkOctetLength(inp, inp.currentPosition, n); err != nil {
+				return -1, b, err
+			}
+			// stop before a run of digits can overflow
+			if n > len(inp.bs) {
+				return -1, b, newParseError(inp, inp.currentPosition, fmt.Sprintf("length of at most %d", len(inp.bs)), nil)
+			}
+		} else {
+			b = inp.currentPosition + i
+			break
+		}
+	}
+	if inp.strict && inp.NextByte() == '0' && b != inp.currentPosition+1 {
+		return -1, b, newParseError(inp, inp.currentPosition, "length without leading zeros", nil)
+	}
+	if b == -1 || inp.bs[b] != ':' {
+		if b == -1 {
+			b = len(inp.bs)
+		}
+		return -1, b, newParseError(inp, b, "':'", nil)
+	}
+	if b+n+1 > len(inp.bs) {
+		return -1, b, newParseError(inp, b+1, fmt.Sprintf("octet string of %d bytes", n), nil)
+	}
+	inp.currentPosition = b + n + 1
+	return n, b, nil
+}
+
+func getOctet(inp *input) (*Node, error) {
+	octStrStart := 0
+	var node Node
+	var octStrLen int
+	var hint []byte
+	var err error
+
+	// An optional display hint, [10:text/plain]5:alice
+	if inp.Remaining() > 0 && inp.NextByte() == LeftSquareBracket {
+		inp.currentPosition++
+		octStrLen, octStrStart, err = getLen(inp)
+		if err != nil {
+			return nil, err
+		}
+		hint = inp.Slice(octStrStart+1, octStrStart+octStrLen+1)
+		if inp.Remaining() == 0 || inp.NextByte() != RightSquareBracket {
+			return nil, newParseError(inp, inp.currentPosition, "']'", nil)
+		}
+		inp.currentPosition++
+	}
+
+	// Get byte array
+	octStrLen, octStrStart, err = getLen(inp)
+	if err != nil {
+		return nil, err
+	}
+	oct := OctetString{
+		Value: inp.Slice(octStrStart+1, octStrStart+octStrLen+1),
+		Hint:  hint,
+	}
+
+	node = newOctetString(&oct)
+	inp.currentPosition = octStrStart + octStrLen + 1
+	return &node, nil
+}
+
+// getPlainOctet reads an octet string that must not carry a display hint,
+// as used for the parts of star forms
+func getPlainOctet(inp *input) (*Node, error) {
+	start := inp.currentPosition
+	node, err := getOctet(inp)
+	if err != nil {
+		return nil, err
+	}
+	if node.octet.Hint != nil {
+		return nil, newParseError(inp, start, "octet string without display hint", nil)
+	}
+	return node, nil
+}
+
+// getParts reads the elements of a list up to and including the closing
+// bracket. brackets counts the open brackets.
+func getParts(inp *input, brackets *int) ([]Node, error) {
+	var element *Node
+	var members []Node
+	var err error
+
+	for {
+		start := inp.currentPosition
+		if inp.Remaining() == 0 {
+			return nil, newParseError(inp, start, "')'", nil)
+		}
+		if inp.NextByte() != RightBracket {
+			if err = inp.limits.checkWidth(inp, start, len(members)+1); err != nil {
+				return nil, err
+			}
+		}
+		if inp.NextByte() == LeftBracket {
+			inp.currentPosition++
+			*brackets++
+			if err = inp.limits.checkDepth(inp, start, *brackets); err != nil {
+				return nil, err
+			}
+			element, err = getSexp(inp, brackets)
+			if err != nil {
+				return nil, err
+			}
+			members = append(members, *element)
+		} else if inp.NextByte() == RightBracket {
+			inp.currentPosition++
+			*brackets--
+			return members, nil
+		} else { // MUST be an octet-string
+			element, err = getOctet(inp)
+			if err != nil {
+				return nil, err
+			}
+			members = append(members, *element)
+		}
+	}
+}
+
+// getSexp reads a list, the opening bracket has already been read
+func getSexp(inp *input, brackets *int) (*Node, error) {
+	var tag *Node
+	var node Node
+	var parts []Node
+	var err error
+
+	path := inp.path
+	// first element MUST be a tag
+	tag, err = getOctet(inp)
+	if err != nil {
+		return nil, err
+	}
+
+	if string(tag.octet.Value) == "*" && tag.octet.Hint == nil {
+		parts, err = getStarForm(inp, brackets)
+		if err != nil {
+			return nil, err
+		}
+		node = parts[0]
+	} else {
+		inp.Enter(string(tag.octet.Value))
+		parts, err = getParts(inp, brackets)
+		if err != nil {
+			return nil, err
+		}
+		node = newSExpression(tag.octet, parts)
+	}
+	inp.path = path
+	return &node, nil
+}
+
+func getStarForm(inp *input, brackets *int) ([]Node, error) {
+	var node *Node
+	var result []Node
+
+	var err error
+	var members []Node
+	var rangeItem *Range
+	var prefix []byte
+	var suffix []byte
+
+	start := inp.currentPosition
+	node, err = getPlainOctet(inp)
+	if err != nil {
+		return nil, err
+	}
+	// First the star form type
+	switch c := string(node.octet.Value); c {
+	case SetStarform:
+		inp.Enter("*" + c)
+		members, err = getSet(inp, brackets)
+		if err != nil {
+			return nil, err
+		}
+		*node = newSet(members)
+	case RangeStarform:
+		inp.Enter("*" + c)
+		rangeItem, err = getRange(inp)
+		if err != nil {
+			return nil, err
+		}
+		*node = newRange(rangeItem)
+	case PrefixStarform:
+		inp.Enter("*" + c)
+		prefix, err = getPrefix(inp)
+		if err != nil {
+			return nil, err
+		}
+		*node = newPrefix(prefix)
+	case SuffixStarform:
+		inp.Enter("*" + c)
+		suffix, err = getSuffix(inp)
+		if err != nil {
+			return nil, err
+		}
+		*node = newSuffix(suffix)
+	default:
+		return nil, newParseError(inp, start, "star form type", nil)
+	}
+	if inp.Remaining() == 0 || inp.NextByte() != RightBracket {
+		return nil, newParseError(inp, inp.currentPosition, "')'", nil)
+	}
+	inp.currentPosition++
+	*brackets--
+	result = append(result, *node)
+	return result, nil
+}
+
+func getSet(inp *input, brackets *int) ([]Node, error) {
+	// set = "3:set" 1*[s-expr / tag]
+	var item *Node
+	var members []Node
+	var err error
+
+	seen := newSetMembers()
+
+	for {
+		start := inp.currentPosition
+		if inp.Remaining() == 0 {
+			return nil, newParseError(inp, start, "')'", nil)
+		}
+		if inp.NextByte() != RightBracket {
+			if err = inp.limits.checkSetSize(inp, start, len(members)+1); err != nil {
+				return nil, err
+			}
+		}
+		if inp.NextByte() == LeftBracket {
+			inp.currentPosition++
+			*brackets++
+			if err = inp.limits.checkDepth(inp, start, *brackets); err != nil {
+				return nil, err
+			}
+			item, err = getSexp(inp, brackets)
+			if err != nil {
+				return nil, err
+			}
+		} else if inp.NextByte() == RightBracket {
+			break
+		} else {
+			item, err = getOctet(inp)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if expected := seen.add(item); expected != "" {
+			return nil, newParseError(inp, start, expected, nil)
+		}
+		members = append(members, *item)
+	}
+	if len(members) == 0 {
+		return nil, newParseError(inp, inp.currentPosition, "set member", nil)
+	}
+
+	return members, nil
+}
+
+// setMembers verifies that there are no two s-expressions with the same
+// tag in a set, the same for octet strings
+type setMembers struct {
+	sexp  map[string]bool
+	octet map[string]bool
+}
+
+func newSetMembers() setMembers {
+	return setMembers{sexp: make(map[string]bool), octet: make(map[string]bool)}
+}
+
+// add records item, it returns what was expected instead if item is a
+// duplicate
+func (seen setMembers) add(item *Node) string {
+	switch item.kind {
+	case KindSExpression:
+		if seen.sexp[string(item.octet.Value)] {
+			return "unique s-expression tag in set"
+		}
+		seen.sexp[string(item.octet.Value)] = true
+	case KindOctetString:
+		if seen.octet[string(item.octet.Value)] {
+			return "unique octet string in set"
+		}
+		seen.octet[string(item.octet.Value)] = true
+	}
+	return ""
+}
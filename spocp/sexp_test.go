@@ -0,0 +1,131 @@
+package spocp
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestSexp(t *testing.T) {
+	var s_expressions = []string{
+		"(11:certificate(6:issuer3:bob)(5:level(1:*5:range7:numeric2:ge3:100)))",
+		// "(11:certificate(6:issuer3:bob)(5:level(1:*5:range5:alpha2:ge3:abc)))",
+		// "(11:certificate(6:issuer3:bob)(5:level(1:*5:range4:ipv42:ge11:130.239.1.12:lt13:130.239.1.127)))",
+		// "(11:certificate(6:issuer3:bob)(5:level(1:*5:range4:ipv62:ge39:FEDC:BA98:7654:3210:FEDC:BA98:7654:3210)))",
+		// "(11:certificate(6:issuer3:bob)(5:level(1:*5:range4:ipv62:ge26:1080:0:0:0:8:800:200C:417A)))",
+		// "(11:certificate(6:issuer3:bob)(5:level(1:*5:range4:ipv62:ge21:1080::8:800:200C:417A)))",
+		// "(11:certificate(6:issuer3:bob)(5:fruit(1:*3:set5:apple6:orange5:lemon)))",
+		// "(1:t(1:*3:set(1:a1:b)(1:c(1:d1:e))(1:f)1:g))",
+		// "(1:t(1:*3:set(1:a(1:x1:y))(1:b1:c)(1:a1:d)))", // invalid
+		// "(11:certificate(6:issuer3:bob)(4:when(1:*5:range4:date2:ge25:2023-12-22T17:25:33+01:00)))",
+		// "(11:certificate(6:issuer3:bob)(4:when(1:*5:range4:time2:ge8:10:30:00)))",
+	}
+	for _, expression := range s_expressions {
+		bs := []byte(expression)
+		var SExpression *Node
+		var err error
+		// Skip the first '('
+		var inp = input{bs: bs, currentPosition: 1}
+		brackets := 1
+
+		SExpression, err = getSexp(&inp, &brackets)
+		if err != nil {
+			log.Fatal(err)
+		}
+		t.Log(SExpression)
+	}
+}
+
+func TestSexpMalformed(t *testing.T) {
+	var s_expressions = map[string]struct {
+		offset int
+		path   string
+	}{
+		"(11:certificate(6:issuer3:bob)(5:level(1:*5:range":         {49, "certificate/level/*range"},
+		"(11:certificate(6:issuer20:bob))":                          {27, "certificate/issuer"},
+		"(11:certificate(6:issuer3bob))":                            {25, "certificate/issuer"},
+		"(11:certificate(5:level(1:*5:range7:numeric2:xx3:100)))":   {43, "certificate/level/*range"},
+		"(11:certificate(5:level(1:*5:range7:numeric2:ge3:1a0)))":   {49, "certificate/level/*range"},
+		"(11:certificate(5:level(1:*5:range5:color2:ge3:100)))":     {34, "certificate/level/*range"},
+		"(11:certificate(5:fruit(1:*3:set5:apple5:apple)))":         {39, "certificate/fruit/*set"},
+		"(11:certificate(5:fruit(1:*3:set)))":                       {32, "certificate/fruit/*set"},
+		"(11:certificate(5:fruit(1:*6:prefix1:a1:b)))":              {38, "certificate/fruit/*prefix"},
+		"(11:certificate(5:fruit(1:*4:star)))":                      {27, "certificate/fruit"},
+		"(1:t(1:*3:set(1:a(1:x1:y))(1:b1:c)(1:a1:d)))":              {34, "t/*set"},
+		"(1:t(1:*3:set(1:a(1:x1:y))(1:b3:c)))":                      {36, "t/*set"},
+		"(11:certificate(5:level(1:*5:range4:ipv42:ge7:1.2.3)))":    {46, "certificate/level/*range"},
+		"(11:certificate(5:level(1:*5:range4:date2:ge3:now)))":      {46, "certificate/level/*range"},
+		"(11:certificate(5:level(1:*5:range7:numeric2:ge3:100) 1:a": {53, "certificate/level"},
+		"(5:level(1:*5:range7:numeric2:ge0:))":                      {34, "level/*range"},
+		"(5:level(1:*5:range7:numeric2:ge2:102:ge1:5))":             {36, "level/*range"},
+		"(5:level(1:*5:range7:numeric2:ge2:102:le1:5))":             {36, "level/*range"},
+	}
+	for expression, expected := range s_expressions {
+		var parseErr *ParseError
+		var inp = input{bs: []byte(expression), currentPosition: 1}
+		brackets := 1
+
+		_, err := getSexp(&inp, &brackets)
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%s: expected a ParseError, got %v", expression, err)
+			continue
+		}
+		if parseErr.Offset != expected.offset {
+			t.Errorf("%s: expected offset %d, got %d (%v)", expression, expected.offset, parseErr.Offset, err)
+		}
+		if path := strings.Join(parseErr.Path, "/"); path != expected.path {
+			t.Errorf("%s: expected path %s, got %s", expression, expected.path, path)
+		}
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	var excerpts = []struct {
+		src     string
+		pos     int
+		excerpt string
+	}{
+		{"(1:a1:b)", 3, "(1:a1:b)\n   ^"},
+		{"(1:a1:b)", 8, "(1:a1:b)\n        ^"},
+		{"(1:a)\n(1:b\x00)\n(1:c)", 9, "(1:b.)\n   ^"},
+		{strings.Repeat("x", 100), 80, strings.Repeat("x", 50) + "\n" + strings.Repeat(" ", 30) + "^"},
+	}
+	for _, e := range excerpts {
+		if got := excerpt([]byte(e.src), e.pos); got != e.excerpt {
+			t.Errorf("%q at %d: expected\n%s\ngot\n%s", e.src, e.pos, e.excerpt, got)
+		}
+	}
+}
+
+func TestSexpCmp(t *testing.T) {
+	var Rule = []string{
+		"(11:certificate(6:issuer3:bob)(7:subject))",
+	}
+	var Query = []string{
+		"(11:certificate(6:issuer3:bob)(7:subject5:alice))",
+	}
+
+	for n := range len(Rule) {
+		var rule, query *Node
+		var err error
+
+		var inp = input{bs: []byte(Rule[n]), currentPosition: 1}
+		brackets := 1
+		rule, err = getSexp(&inp, &brackets)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		inp = input{bs: []byte(Query[n]), currentPosition: 1}
+		brackets = 1
+		query, err = getSexp(&inp, &brackets)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cmp := query.Compare(*rule)
+		if !cmp.Matched() {
+			t.Errorf("%s against %s: %v", Query[n], Rule[n], cmp)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Package spocp implements SPOCP S-expressions: parsing of the canonical,
+// advanced and transport forms, serialization and matching of queries
+// against rules.
+//
+// Parse turns the canonical form, e.g. (11:certificate(6:issuer3:bob)),
+// the advanced form, (certificate (issuer bob)), or the transport form,
+// {KDExOmNlcnRpZmljYXRlKDY6aXNzdWVyMzpib2IpKQ==}, into a Node tree.
+// Marshal turns a Node back into bytes and Match decides whether a query
+// is less permissive than, or equal to, a rule.
+package spocp
+
+import (
+	"bytes"
+	"errors"
+)
+
+// Parser parses S-expressions within the given Limits. A Strict parser
+// accepts exactly one S-expression in canonical form: lengths must not have
+// leading zeros, nothing may follow the closing bracket and the advanced and
+// transport forms are rejected. Hashes and signatures over input accepted
+// in strict mode are stable since it is the only encoding of its Node.
+// Collator, if set, orders the values of the alpha ranges parsed, see
+// Range.SetCollator.
+type Parser struct {
+	Limits   Limits
+	Strict   bool
+	Collator Collator
+}
+
+// Parse parses a single S-expression within DefaultLimits, see Parser.Parse
+func Parse(data []byte) (*Node, error) {
+	p := Parser{Limits: DefaultLimits}
+	return p.Parse(data)
+}
+
+// Parse parses a single S-expression. Input starting with a length prefix
+// directly after the opening '(' is read as the canonical form, unless it
+// turns out to be the advanced form, as (5:level 100) or (10 apples) are.
+// The transport form is recognised by the opening '{', anything else is
+// read as the advanced form, see ParseAdvanced. A Strict parser only
+// accepts the canonical form.
+func (p *Parser) Parse(data []byte) (*Node, error) {
+	if err := p.Limits.checkSize(&input{bs: data}); err != nil {
+		return nil, err
+	}
+	if p.Strict {
+		return p.parseCanonical(data)
+	}
+	if len(data) > 1 && data[0] == LeftBracket && canonicalList(data[1:]) {
+		node, err := p.parseCanonical(data)
+		if !advancedSyntax(data, err) {
+			return node, err
+		}
+	}
+	if trimmed := bytes.TrimLeft(data, " \t\r\n\f\v"); len(trimmed) > 0 && trimmed[0] == LeftBrace {
+		return p.parseTransport(data)
+	}
+	return p.parseAdvanced(data)
+}
+
+// canonicalList reports whether the bytes following an opening '(' start a
+// list in canonical form, a length prefix or a display hint with one
+func canonicalList(data []byte) bool {
+	return len(data) > 0 && (digit(data[0]) ||
+		len(data) > 1 && data[0] == LeftSquareBracket && digit(data[1]))
+}
+
+// advancedSyntax reports whether err, from parsing data in canonical form,
+// was found where only the advanced form goes on: at whitespace, or at
+// digits not followed by ':', which start a token
+func advancedSyntax(data []byte, err error) bool {
+	var parseErr *ParseError
+
+	if !errors.As(err, &parseErr) {
+		return false
+	}
+	return parseErr.Expected == "':'" ||
+		parseErr.Offset < len(data) && isSpace(data[parseErr.Offset])
+}
+
+// parseCanonical parses a single S-expression in canonical form. data must
+// start with '(' and the list must be closed.
+func (p *Parser) parseCanonical(data []byte) (*Node, error) {
+	inp := input{bs: data, limits: p.Limits, strict: p.Strict, collator: p.Collator}
+	if inp.Remaining() == 0 || inp.NextByte() != LeftBracket {
+		return nil, newParseError(&inp, 0, "'('", nil)
+	}
+	inp.currentPosition++
+	brackets := 1
+	node, err := getSexp(&inp, &brackets)
+	if err != nil {
+		return nil, err
+	}
+	// as in the other forms only whitespace may follow, unless strict
+	rest := inp.bs[inp.currentPosition:]
+	if !inp.strict {
+		rest = bytes.TrimLeft(rest, " \t\r\n\f\v")
+	}
+	if len(rest) > 0 {
+		return nil, newParseError(&inp, len(inp.bs)-len(rest), "end of input", nil)
+	}
+	return node, nil
+}
+
+// Marshal returns the canonical form of node
+func Marshal(node *Node) ([]byte, error) {
+	return AppendCanonical(nil, node)
+}
+
+// Match reports whether query is less permissive than, or equal to, rule.
+// It returns an error instead of deciding when the comparison cannot be
+// made, LessOrEqualTo returns the Decision itself.
+func Match(query, rule *Node) (bool, error) {
+	d := LessOrEqualTo(*query, *rule)
+	if d.Outcome == OutcomeError {
+		return false, d.Err
+	}
+	return d.Matched(), nil
+}
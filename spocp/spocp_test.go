@@ -0,0 +1,270 @@
+package spocp
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestParseMarshal(t *testing.T) {
+	var s_expressions = []string{
+		"(11:certificate(6:issuer3:bob)(7:subject5:alice))",
+		"(11:certificate(6:issuer3:bob)(7:subject))",
+		"(1:a(1:b(1:c(1:d))))",
+		"(3:key3:)()(1:(2:)))(1:*3:set1:(1:)))",
+		"(11:certificate(6:issuer3:bob)(5:level(1:*5:range7:numeric2:ge3:100)))",
+		"(11:certificate(6:issuer3:bob)(5:level(1:*5:range7:numeric2:gt2:102:lt3:100)))",
+		"(11:certificate(5:level(1:*5:range4:ipv42:ge11:130.239.1.12:lt13:130.239.1.127)))",
+		"(11:certificate(5:level(1:*5:range4:ipv62:ge21:1080::8:800:200C:417A)))",
+		"(11:certificate(4:when(1:*5:range4:date2:ge29:2023-12-22T17:25:33.500+01:00)))",
+		"(11:certificate(4:when(1:*5:range4:time2:ge8:10:30:002:le8:18:00:00)))",
+		"(11:certificate(4:name(1:*5:range5:alpha2:ge3:abc)))",
+		"(11:certificate(5:fruit(1:*3:set5:apple6:orange5:lemon)))",
+		"(1:t(1:*3:set(1:a1:b)(1:c(1:d1:e))(1:f)1:g))",
+		"(1:t(1:*3:set(1:*6:prefix1:a)(1:*6:suffix1:z)1:g))",
+		"(4:file(1:*6:prefix6:/home/))",
+		"(4:host(1:*6:suffix12:.example.org))",
+		"(4:cert[21:application/pkix-cert]4:\x30\x82\x01\x0a)",
+		"([10:text/plain]4:user5:alice)",
+	}
+	for _, expression := range s_expressions {
+		node, err := Parse([]byte(expression))
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := Marshal(node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(out, []byte(expression)) {
+			t.Errorf("expected %s, got %s", expression, out)
+		}
+	}
+}
+
+func TestDisplayHint(t *testing.T) {
+	var rules = map[string]map[string]bool{
+		"(4:user5:alice)": {
+			"(4:user5:alice)":                true,
+			"(4:user[10:text/plain]5:alice)": true,
+			"(4:user[10:text/plain]3:bob)":   false,
+		},
+		"(4:user[10:text/plain]5:alice)": {
+			"(4:user5:alice)":                false,
+			"(4:user[10:text/plain]5:alice)": true,
+			"(4:user[9:text/html]5:alice)":   false,
+		},
+		"(4:user(1:*3:set[10:text/plain]5:alice3:bob))": {
+			"(4:user[10:text/plain]5:alice)": true,
+			"(4:user[10:text/plain]3:bob)":   true,
+		},
+	}
+	for stringRule, queries := range rules {
+		rule, err := Parse([]byte(stringRule))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for stringQuery, expected := range queries {
+			query, err := Parse([]byte(stringQuery))
+			if err != nil {
+				t.Fatal(err)
+			}
+			cmp, err := Match(query, rule)
+			if err != nil {
+				t.Fatalf("%s: %v", stringQuery, err)
+			}
+			if cmp != expected {
+				t.Errorf("%s against %s: expected %v, got %v", stringQuery, stringRule, expected, cmp)
+			}
+		}
+	}
+	// an empty hint is not the same as none
+	emptyHint := &OctetString{Value: []byte("alice"), Hint: []byte{}}
+	if OctetStringCompare(&OctetString{Value: []byte("alice")}, emptyHint).Matched() {
+		t.Error("expected a query without a hint not to match an empty hint")
+	}
+	if !OctetStringCompare(emptyHint, emptyHint).Matched() {
+		t.Error("expected an empty hint to match an empty hint")
+	}
+
+	var malformed = []string{
+		"(4:user[10:text/plain5:alice)",
+		"(4:user[10:text/plain]",
+		"(4:user(1:*6:prefix[10:text/plain]1:a))",
+		"(4:user(1:*5:range7:numeric2:ge[10:text/plain]1:1))",
+	}
+	for _, expression := range malformed {
+		var parseErr *ParseError
+
+		_, err := Parse([]byte(expression))
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%q: expected a ParseError, got %v", expression, err)
+		}
+	}
+}
+
+func TestAppendCanonical(t *testing.T) {
+	node, err := Parse([]byte("(4:file(1:*6:prefix6:/home/))"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := AppendCanonical([]byte("rule: "), node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "rule: (4:file(1:*6:prefix6:/home/))" {
+		t.Errorf("unexpected output %s", out)
+	}
+}
+
+func TestParseUnbalanced(t *testing.T) {
+	var s_expressions = []string{
+		"",
+		"11:certificate",
+		"(11:certificate(6:issuer3:bob)",
+	}
+	for _, expression := range s_expressions {
+		var parseErr *ParseError
+
+		_, err := Parse([]byte(expression))
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%q: expected a ParseError, got %v", expression, err)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	var SExpressions = map[string]map[string]bool{
+		"(11:certificate(6:issuer3:bob)(4:when(1:*5:range4:time2:ge8:10:30:00)))": {
+			"(11:certificate(6:issuer3:bob)(4:when8:12:00:00))": true,
+			"(11:certificate(6:issuer3:bob)(4:when8:09:00:00))": false,
+		},
+		"(11:certificate(6:issuer3:bob)(4:when(1:*5:range4:date2:ge25:2023-12-22T17:25:33+01:002:le25:2030-12-31T23" +
+			":59:59+01:00)))": {
+			"(11:certificate(6:issuer3:bob)(4:when25:2025-03-05T11:00:00+01:00))": true,
+			"(11:certificate(6:issuer3:bob)(4:when25:2020-03-05T11:00:00+01:00))": false,
+			"(11:certificate(6:issuer3:bob)(4:when25:2035-03-05T11:00:00+01:00))": false,
+		},
+		"(11:certificate(6:issuer3:bob)(5:fruit(1:*3:set5:apple6:orange5:lemon)))": {
+			"(11:certificate(6:issuer3:bob)(5:fruit5:apple))":  true,
+			"(11:certificate(6:issuer3:bob)(5:fruit6:orange))": true,
+			"(11:certificate(6:issuer3:bob)(5:fruit5:lemon))":  true,
+		},
+	}
+	for stringRule, queries := range SExpressions {
+		rule, err := Parse([]byte(stringRule))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for stringQuery, expected := range queries {
+			query, err := Parse([]byte(stringQuery))
+			if err != nil {
+				t.Fatal(err)
+			}
+			cmp, err := Match(query, rule)
+			if err != nil {
+				t.Fatalf("%s: %v", stringQuery, err)
+			}
+			if cmp != expected {
+				t.Errorf("%s against %s: expected %v, got %v", stringQuery, stringRule, expected, cmp)
+			}
+		}
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var s_expressions = map[string]string{
+		"{KDExOmNlcnRpZmljYXRlKDY6aXNzdWVyMzpib2IpKQ==}":         "(11:certificate(6:issuer3:bob))",
+		"  {KDExOmNlcnRpZmlj\n  YXRlKDY6aXNzdWVyMzpib2IpKQ==}\n": "(11:certificate(6:issuer3:bob))",
+		"{KDExOmNlcnRpZmljYXRlKDY6aXNzdWVyMzpib2IpKQ}":           "(11:certificate(6:issuer3:bob))",
+	}
+	for expression, canonical := range s_expressions {
+		node, err := Parse([]byte(expression))
+		if err != nil {
+			t.Errorf("%q: %v", expression, err)
+			continue
+		}
+		out, err := Marshal(node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != canonical {
+			t.Errorf("%q: expected %s, got %s", expression, canonical, out)
+		}
+		out, err = MarshalTransport(node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != "{KDExOmNlcnRpZmljYXRlKDY6aXNzdWVyMzpib2IpKQ==}" {
+			t.Errorf("unexpected transport form %s", out)
+		}
+	}
+
+	var malformed = []string{
+		"{KDExOmNlcnRpZmljYXRlKDY6aXNzdWVyMzpib2IpKQ==",
+		"{KDExOmNlcnRpZmljYXRlKDY6aXNzdWVyMzpib2IpKQ==} (1:a)",
+		"{KDExOmNlcnRp*mljYXRlKDY6aXNzdWVyMzpib2IpKQ==}",
+		"{KDExOmNlcnRpZmljYXRlKDY6aXNzdWVyMzpib2I=}",
+	}
+	for _, expression := range malformed {
+		var parseErr *ParseError
+
+		_, err := Parse([]byte(expression))
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%q: expected a ParseError, got %v", expression, err)
+		}
+	}
+}
+
+func TestStrict(t *testing.T) {
+	var s_expressions = map[string]bool{
+		"(11:certificate(6:issuer3:bob))":                true,
+		"(11:certificate(6:issuer0:))":                   true,
+		"(4:user[10:text/plain]5:alice)":                 true,
+		"(11:certificate(6:issuer03:bob))":               false,
+		"(11:certificate(6:issuer00:))":                  false,
+		"(011:certificate(6:issuer3:bob))":               false,
+		"(11:certificate(6:issuer3:bob))trailing":        false,
+		"(11:certificate(6:issuer3:bob)) ":               false,
+		"(11:certificate(6:issuer3:bob)))":               false,
+		"(certificate (issuer bob))":                     false,
+		"{KDExOmNlcnRpZmljYXRlKDY6aXNzdWVyMzpib2IpKQ==}": false,
+		" (11:certificate(6:issuer3:bob))":               false,
+	}
+	strict := Parser{Strict: true}
+	for expression, valid := range s_expressions {
+		var parseErr *ParseError
+
+		_, err := strict.Parse([]byte(expression))
+		if valid && err != nil {
+			t.Errorf("%q: %v", expression, err)
+		} else if !valid && !errors.As(err, &parseErr) {
+			t.Errorf("%q: expected a ParseError, got %v", expression, err)
+		}
+	}
+
+	// the lax parser accepts leading zeros and whitespace around the list,
+	// not other trailing input
+	lax := Parser{}
+	for _, expression := range []string{
+		"(11:certificate(6:issuer0:))",
+		"(11:certificate(6:issuer03:bob))",
+		"(11:certificate(6:issuer3:bob)) \n",
+	} {
+		if _, err := lax.Parse([]byte(expression)); err != nil {
+			t.Errorf("%q: %v", expression, err)
+		}
+	}
+	for expression, offset := range map[string]int{
+		"(11:certificate(6:issuer3:bob))trailing": 31,
+		"(3:abc)garbage":  7,
+		"(3:abc) (3:def)": 8,
+	} {
+		var parseErr *ParseError
+
+		_, err := lax.Parse([]byte(expression))
+		if !errors.As(err, &parseErr) || parseErr.Offset != offset {
+			t.Errorf("%q: expected a ParseError at offset %d, got %v", expression, offset, err)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package spocp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+)
+
+// type StarForm struct {
+// 	valueType byte
+// 	boundary  [2][]byte
+// 	limit     [2]any
+// }
+
+const (
+	ALPHA   = "Alpha"
+	NUMERIC = "Numeric"
+	DATE    = "Date"
+	TIME    = "Time"
+	IPV4    = "Ipv4"
+	IPV6    = "Ipv6"
+)
+
+var Alpha = []byte{'a', 'l', 'p', 'h', 'a'}
+var Numeric = []byte{'n', 'u', 'm', 'e', 'r', 'i', 'c'}
+var Date = []byte{'d', 'a', 't', 'e'}
+var Time = []byte{'t', 'i', 'm', 'e'}
+var Ipv4 = []byte{'i', 'p', 'v', '4'}
+var Ipv6 = []byte{'i', 'p', 'v', '6'}
+
+// RangeTypeName returns the name used in S-expressions for a range value type
+func RangeTypeName(valueType string) []byte {
+	switch valueType {
+	case ALPHA:
+		return Alpha
+	case NUMERIC:
+		return Numeric
+	case DATE:
+		return Date
+	case TIME:
+		return Time
+	case IPV4:
+		return Ipv4
+	case IPV6:
+		return Ipv6
+	}
+	return nil
+}
+
+// timeOfDay is the layout of the limits of a time range
+const timeOfDay = "15:04:05"
+
+// rangeValueType returns the value type of a range type name, the reverse
+// of RangeTypeName, or "" if there is no such type
+func rangeValueType(name []byte) string {
+	if bytes.Equal(Alpha, name) {
+		return ALPHA
+	} else if bytes.Equal(Numeric, name) {
+		return NUMERIC
+	} else if bytes.Equal(Date, name) {
+		return DATE
+	} else if bytes.Equal(Time, name) {
+		return TIME
+	} else if bytes.Equal(Ipv4, name) {
+		return IPV4
+	} else if bytes.Equal(Ipv6, name) {
+		return IPV6
+	}
+	return ""
+}
+
+var limits = []string{"le", "lt", "ge", "gt"}
+
+func correctLimit(val string) bool {
+	// tests that the given limit type (ge, gt, ...) is one that is expected
+	for _, lim := range limits {
+		if lim == val {
+			return true
+		}
+	}
+	return false
+}
+
+func getLimit(inp *input) (string, []byte, error) {
+	var gogeLole, value *Node
+	var err error
+	var limValue string
+
+	start := inp.currentPosition
+	gogeLole, err = getPlainOctet(inp)
+	if err != nil {
+		return "", nil, err
+	}
+	limValue = string(gogeLole.octet.Value)
+	if correctLimit(limValue) == false {
+		return "", nil, newParseError(inp, start, "range boundary (le, lt, ge or gt)", nil)
+	}
+
+	value, err = getPlainOctet(inp)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return limValue, value.octet.Value, nil
+}
+
+func verifyAlpha(rng *Range, value []byte, n int) error {
+	// any octet string is an alpha limit, it is kept in rawLimit
+	return nil
+}
+
+// digits reports whether value holds only decimal digits, numbers of any
+// size are compared as digits
+func digits(value []byte) bool {
+	for _, b := range value {
+		if !digit(b) {
+			return false
+		}
+	}
+	return true
+}
+
+func verifyIPv4(rng *Range, value []byte, n int) error {
+	var err error
+	var addr netip.Addr
+
+	addr, err = netip.ParseAddr(string(value))
+	if err != nil {
+		return fmt.Errorf("not an IP address: %v", addr)
+	}
+	if !addr.Is4() {
+		return errors.New("not an IPv4 address, but IPv6")
+	}
+	rng.ipv4Limit[n] = addr
+	return nil
+}
+
+func verifyIPv6(rng *Range, value []byte, n int) error {
+	var err error
+	var addr netip.Addr
+
+	addr, err = netip.ParseAddr(string(value))
+	if err != nil {
+		return fmt.Errorf("not an IP address: %v", addr)
+	}
+	if !addr.Is6() {
+		return errors.New("not an IPv6 address, but IPv4")
+	}
+	rng.ipv6Limit[n] = addr
+	return nil
+}
+
+func verifyNumeric(rng *Range, value []byte, n int) error {
+	// the limit is kept in rawLimit
+	if len(value) == 0 || !digits(value) {
+		return fmt.Errorf("not a number: %q", value)
+	}
+	return nil
+}
+
+func verifyDate(rng *Range, value []byte, n int) error {
+	var err error
+
+	t, err := time.Parse(time.RFC3339, string(value))
+	if err != nil {
+		return err
+	}
+	rng.dateLimit[n] = t
+	return nil
+}
+
+func verifyTime(rng *Range, value []byte, n int) error {
+	var err error
+
+	t, err := time.Parse(timeOfDay, string(value))
+	if err != nil {
+		return err
+	}
+	rng.timeLimit[n] = t
+	return nil
+}
+
+func getRestrictions(inp *input, rng *Range, n int) error {
+	var limit string
+	var value []byte
+	var err error
+
+	limit, value, err = getLimit(inp)
+	if err != nil {
+		return err
+	}
+	rng.boundary[n] = limit
+	rng.rawLimit[n] = value
+	// position of the limit value, used when reporting a value that does not verify
+	start := inp.currentPosition - len(value)
+
+	err = verifyLimit(rng, value, n)
+	if err != nil {
+		return newParseError(inp, start, rng.valueType+" range limit", err)
+	}
+
+	return nil
+}
+
+// verifyLimit checks that value is a limit of the range's value type and
+// stores it as limit n
+func verifyLimit(rng *Range, value []byte, n int) error {
+	if rng.valueType == ALPHA {
+		return verifyAlpha(rng, value, n)
+	} else if rng.valueType == NUMERIC {
+		return verifyNumeric(rng, value, n)
+	} else if rng.valueType == IPV4 {
+		return verifyIPv4(rng, value, n)
+	} else if rng.valueType == DATE {
+		return verifyDate(rng, value, n)
+	} else if rng.valueType == TIME {
+		return verifyTime(rng, value, n)
+	} else if rng.valueType == IPV6 {
+		return verifyIPv6(rng, value, n)
+	}
+	return fmt.Errorf("unknown range type %q", rng.valueType)
+}
+
+// verifyBounds checks that the two limits of a range are on different sides
+// and that some value is within them
+func verifyBounds(rng *Range) error {
+	lower, upper := 0, 1
+	if !lowerBoundary(rng.boundary[lower]) {
+		lower, upper = upper, lower
+	}
+	if lowerBoundary(rng.boundary[lower]) == lowerBoundary(rng.boundary[upper]) {
+		return fmt.Errorf("%s and %s limit the same side", rng.boundary[0], rng.boundary[1])
+	}
+	closed, empty := closedLimit(rng, lower)
+	if !empty {
+		closed, empty = closedLimit(closed, upper)
+	}
+	if !empty {
+		cmp, err := compareLimits(closed, closed, lower, upper)
+		if err != nil {
+			return err
+		}
+		empty = cmp > 0 || cmp == 0 && (closed.boundary[lower] == "gt" || closed.boundary[upper] == "lt")
+	}
+	if empty {
+		return fmt.Errorf("no value is %s %s and %s %s", rng.boundary[lower], rng.rawLimit[lower],
+			rng.boundary[upper], rng.rawLimit[upper])
+	}
+	return nil
+}
+
+func getRange(inp *input) (*Range, error) {
+	var rangeType *Node
+	var err error
+	var starRange Range
+
+	// range type
+	start := inp.currentPosition
+	rangeType, err = getPlainOctet(inp)
+	if err != nil {
+		return nil, err
+	}
+
+	starRange.valueType = rangeValueType(rangeType.octet.Value)
+	if starRange.valueType == "" {
+		return nil, newParseError(inp, start, "range type", nil)
+	}
+	if starRange.valueType == ALPHA {
+		starRange.collator = inp.collator
+	}
+
+	err = getRestrictions(inp, &starRange, 0)
+	if err != nil {
+		return nil, err
+	}
+	if inp.Remaining() > 0 && inp.NextByte() != ')' {
+		start = inp.currentPosition
+		err = getRestrictions(inp, &starRange, 1)
+		if err != nil {
+			return nil, err
+		}
+		if err = verifyBounds(&starRange); err != nil {
+			return nil, newParseError(inp, start, "range limits", err)
+		}
+	}
+
+	return &starRange, nil
+}
+
+func getPrefix(inp *input) ([]byte, error) {
+	node, err := getPlainOctet(inp)
+	if err != nil {
+		return nil, err
+	}
+	return node.octet.Value, nil
+}
+
+func getSuffix(inp *input) ([]byte, error) {
+	node, err := getPlainOctet(inp)
+	if err != nil {
+		return nil, err
+	}
+	return node.octet.Value, nil
+}
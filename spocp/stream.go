@@ -0,0 +1,350 @@
+package spocp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Decoder reads successive S-expressions from a stream. The expressions
+// may be in canonical, advanced or transport form and may be separated by
+// whitespace. Only the bytes of the expression being decoded are held in
+// memory, Parser.Limits.MaxSize bounds their number.
+type Decoder struct {
+	// Parser parses each expression, NewDecoder sets DefaultLimits
+	Parser Parser
+	r      *bufio.Reader
+	// offset is the number of bytes consumed from r
+	offset int64
+	buf    bytes.Buffer
+}
+
+// NewDecoder returns a Decoder reading from r
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		Parser: Parser{Limits: DefaultLimits},
+		r:      bufio.NewReader(r),
+	}
+}
+
+// InputOffset returns the number of bytes read from the stream so far
+func (dec *Decoder) InputOffset() int64 {
+	return dec.offset
+}
+
+// Decode reads the next S-expression from the stream. It returns io.EOF
+// when the stream ends before another expression starts. Offsets in a
+// ParseError are counted from the start of the stream, except for errors
+// within a transport form which refer to the decoded canonical form.
+func (dec *Decoder) Decode() (*Node, error) {
+	var parseErr *ParseError
+	var node *Node
+
+	c, err := dec.skipSpace()
+	if err != nil {
+		return nil, err
+	}
+	start := dec.offset
+	dec.buf.Reset()
+	dec.buf.WriteByte(c)
+	dec.offset++
+
+	switch c {
+	case LeftBrace:
+		err = dec.readUntil(RightBrace)
+	case LeftBracket:
+		var next []byte
+		next, err = dec.peek()
+		if err == nil && canonicalList(next) {
+			err = dec.readCanonical()
+		} else if err == nil {
+			err = dec.readAdvanced(1, false)
+		}
+	default:
+		err = dec.fail(start, "'(' or '{'", nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// the node shares its strings with the input, buf is reused
+	node, err = dec.Parser.Parse(bytes.Clone(dec.buf.Bytes()))
+	// errors in the transport form refer to the decoded form
+	if errors.As(err, &parseErr) && c != LeftBrace {
+		parseErr.Offset += int(start)
+	}
+	return node, err
+}
+
+// fail returns a ParseError for stream position pos, quoting the bytes of
+// the current expression
+func (dec *Decoder) fail(pos int64, expected string, err error) error {
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	inp := input{bs: dec.buf.Bytes()}
+	local := int(pos - dec.offset + int64(dec.buf.Len()))
+	parseErr := newParseError(&inp, local, expected, err)
+	parseErr.Offset = int(pos)
+	return parseErr
+}
+
+// peek returns the next two bytes, or the one left in the stream
+func (dec *Decoder) peek() ([]byte, error) {
+	bs, err := dec.r.Peek(2)
+	if len(bs) > 0 {
+		return bs, nil
+	}
+	return nil, err
+}
+
+// checkSize verifies that n more bytes fit within the maximum size
+func (dec *Decoder) checkSize(n int64) error {
+	maxSize := int64(dec.Parser.Limits.MaxSize)
+	if maxSize > 0 && int64(dec.buf.Len())+n > maxSize {
+		pos := dec.offset - int64(dec.buf.Len()) + maxSize
+		return dec.fail(pos, "input of at most "+strconv.FormatInt(maxSize, 10)+" bytes", ErrInputTooLarge)
+	}
+	return nil
+}
+
+func (dec *Decoder) readByte() (byte, error) {
+	if err := dec.checkSize(1); err != nil {
+		return 0, err
+	}
+	c, err := dec.r.ReadByte()
+	if err != nil {
+		return 0, dec.fail(dec.offset, "')'", err)
+	}
+	dec.offset++
+	dec.buf.WriteByte(c)
+	return c, nil
+}
+
+// readN copies n bytes of an octet string into the buffer
+func (dec *Decoder) readN(n int64) error {
+	if err := dec.checkSize(n); err != nil {
+		return err
+	}
+	copied, err := io.CopyN(&dec.buf, dec.r, n)
+	dec.offset += copied
+	if err != nil {
+		return dec.fail(dec.offset, "octet string of "+strconv.FormatInt(n, 10)+" bytes", err)
+	}
+	return nil
+}
+
+// skipSpace discards whitespace and returns the first other byte
+func (dec *Decoder) skipSpace() (byte, error) {
+	for {
+		c, err := dec.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if !isSpace(c) {
+			return c, nil
+		}
+		dec.offset++
+	}
+}
+
+// readUntil reads up to and including delim
+func (dec *Decoder) readUntil(delim byte) error {
+	for {
+		c, err := dec.readByte()
+		if err != nil {
+			return err
+		}
+		if c == delim {
+			return nil
+		}
+	}
+}
+
+// readLength reads the digits of a length prefix following first, up to
+// and including the byte after them. A length above the maximum octet
+// length is returned as one more than the maximum.
+func (dec *Decoder) readLength(first byte) (int64, byte, error) {
+	n := int64(first - '0')
+	maxLength := dec.maxOctetLength()
+	for {
+		c, err := dec.readByte()
+		if err != nil {
+			return 0, 0, err
+		}
+		if !digit(c) {
+			return n, c, nil
+		}
+		n = min(n*10+int64(c-'0'), maxLength+1)
+	}
+}
+
+func (dec *Decoder) maxOctetLength() int64 {
+	if dec.Parser.Limits.MaxOctetLength > 0 {
+		return int64(dec.Parser.Limits.MaxOctetLength)
+	}
+	return math.MaxInt32
+}
+
+// checkLength verifies a length prefix n read from pos
+func (dec *Decoder) checkLength(pos int64, n int64) error {
+	if maxLength := dec.maxOctetLength(); n > maxLength {
+		return dec.fail(pos, "octet string of at most "+strconv.FormatInt(maxLength, 10)+" bytes", ErrOctetTooLong)
+	}
+	return nil
+}
+
+// readCanonical reads the rest of a canonical S-expression whose opening
+// bracket has been read
+func (dec *Decoder) readCanonical() error {
+	depth := 1
+	for depth > 0 {
+		c, err := dec.readByte()
+		if err != nil {
+			return err
+		}
+		switch {
+		case c == LeftBracket:
+			depth++
+		case c == RightBracket:
+			depth--
+		case c == LeftSquareBracket || c == RightSquareBracket:
+		case digit(c):
+			start := dec.offset - 1
+			n, sep, err := dec.readLength(c)
+			if err != nil {
+				return err
+			}
+			if sep != ':' {
+				// digits not followed by ':' start a token, the list is
+				// in advanced form as Parse will find
+				if depth, err = dec.readAfterLength(start, n, sep, depth); err != nil {
+					return err
+				}
+				return dec.readAdvanced(depth, isTokenChar(sep))
+			}
+			if err = dec.checkLength(start, n); err != nil {
+				return err
+			}
+			if err = dec.readN(n); err != nil {
+				return err
+			}
+		case isSpace(c):
+			// only the advanced form separates strings with whitespace
+			return dec.readAdvanced(depth, false)
+		default:
+			return dec.fail(dec.offset-1, "'(', ')' or octet string", nil)
+		}
+	}
+	return nil
+}
+
+// readAdvanced reads the rest of an advanced S-expression with depth lists
+// open, token is set while inside a token, where digits are not a length.
+// Only the structure needed to find the closing bracket is checked here,
+// Parse does the rest.
+func (dec *Decoder) readAdvanced(depth int, token bool) error {
+	for depth > 0 {
+		c, err := dec.readByte()
+		if err != nil {
+			return err
+		}
+		switch {
+		case c == LeftBracket:
+			depth++
+		case c == RightBracket:
+			depth--
+		case c == '"':
+			if err = dec.readQuoted(); err != nil {
+				return err
+			}
+		case c == '#' || c == '|':
+			if err = dec.readUntil(c); err != nil {
+				return err
+			}
+		case digit(c) && !token:
+			start := dec.offset - 1
+			n, sep, err := dec.readLength(c)
+			if err != nil {
+				return err
+			}
+			if depth, err = dec.readAfterLength(start, n, sep, depth); err != nil {
+				return err
+			}
+			token = isTokenChar(sep)
+			continue
+		}
+		token = isTokenChar(c)
+	}
+	return nil
+}
+
+// readAfterLength reads the string that digits from pos followed by sep
+// give the length of in advanced form, nothing if they start a token, and
+// returns the depth after sep
+func (dec *Decoder) readAfterLength(pos int64, n int64, sep byte, depth int) (int, error) {
+	var err error
+
+	if sep == ':' || sep == '"' || sep == '#' || sep == '|' {
+		if err = dec.checkLength(pos, n); err != nil {
+			return depth, err
+		}
+	}
+	switch {
+	case sep == ':':
+		err = dec.readN(n)
+	case sep == '"':
+		err = dec.readQuoted()
+	case sep == '#' || sep == '|':
+		err = dec.readUntil(sep)
+	case sep == LeftBracket:
+		depth++
+	case sep == RightBracket:
+		depth--
+	}
+	return depth, err
+}
+
+// readQuoted reads the rest of a quoted string
+func (dec *Decoder) readQuoted() error {
+	for {
+		c, err := dec.readByte()
+		if err != nil {
+			return err
+		}
+		if c == '"' {
+			return nil
+		}
+		if c == '\\' {
+			if _, err = dec.readByte(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Encoder writes S-expressions to a stream in canonical form
+type Encoder struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewEncoder returns an Encoder writing to w
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes the canonical form of node to the stream
+func (enc *Encoder) Encode(node *Node) error {
+	var err error
+
+	enc.buf, err = AppendCanonical(enc.buf[:0], node)
+	if err != nil {
+		return err
+	}
+	_, err = enc.w.Write(enc.buf)
+	return err
+}
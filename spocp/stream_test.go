@@ -0,0 +1,134 @@
+package spocp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecoder(t *testing.T) {
+	var stream = "(11:certificate(6:issuer3:bob))\n" +
+		"(11:certificate(6:issuer3:bob)(5:level(1:*5:range7:numeric2:ge3:100)))" +
+		"  (certificate (issuer \"bob (the builder)\") (level (* range numeric ge 100)))\n" +
+		"(when (* range time ge 8:10:30:00) (key 3:)()) (mark 130.239.1.1 |KCk=| #2829#))\n" +
+		"\t{KDExOmNlcnRpZmljYXRlKDY6aXNzdWVyMzpib2IpKQ==}\n" +
+		"(4:user[10:text/plain]5:alice)\n" +
+		"([text/plain]user alice)" +
+		"(11:certificate (issuer bob))(5:level 100) (10 apples)\n"
+	var expected = []string{
+		"(11:certificate(6:issuer3:bob))",
+		"(11:certificate(6:issuer3:bob)(5:level(1:*5:range7:numeric2:ge3:100)))",
+		"(11:certificate(6:issuer17:bob (the builder))(5:level(1:*5:range7:numeric2:ge3:100)))",
+		"(4:when(1:*5:range4:time2:ge8:10:30:00)(3:key3:)())(4:mark11:130.239.1.12:()2:()))",
+		"(11:certificate(6:issuer3:bob))",
+		"(4:user[10:text/plain]5:alice)",
+		"([10:text/plain]4:user5:alice)",
+		"(11:certificate(6:issuer3:bob))",
+		"(5:level3:100)",
+		"(2:106:apples)",
+	}
+
+	dec := NewDecoder(strings.NewReader(stream))
+	for _, canonical := range expected {
+		node, err := dec.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := Marshal(node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != canonical {
+			t.Errorf("expected %q, got %q", canonical, out)
+		}
+	}
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+	if dec.InputOffset() != int64(len(stream)) {
+		t.Errorf("expected offset %d, got %d", len(stream), dec.InputOffset())
+	}
+
+	// a node must not change when the next expression is decoded
+	dec = NewDecoder(strings.NewReader("(3:foo3:bar)(3:xyz3:qqq)"))
+	first, err := dec.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = dec.Decode(); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := Marshal(first); err != nil || string(out) != "(3:foo3:bar)" {
+		t.Errorf("expected the first node to be kept, got %q, %v", out, err)
+	}
+}
+
+func TestDecoderMalformed(t *testing.T) {
+	var streams = map[string]struct {
+		offset int
+		err    error
+	}{
+		"(1:a)(11:certificate(6:issuer3:bob)":     {35, io.ErrUnexpectedEOF},
+		"(1:a)\n(11:certificate(6:issuer10:bob))": {38, io.ErrUnexpectedEOF},
+		"(1:a) x":                                 {6, nil},
+		"(1:a) (certificate (issuer \"bob))":      {33, io.ErrUnexpectedEOF},
+		"(1:a) (11:certificate(6:issuer3:bob)x)":  {36, nil},
+		"(1:a) (certificate (issuer #0g#))":       {28, nil},
+		"(1:a) {KDExOmNlcnRpZmljYXRlKDY6aXNzdWVy": {39, io.ErrUnexpectedEOF},
+	}
+	for stream, expected := range streams {
+		var parseErr *ParseError
+
+		dec := NewDecoder(strings.NewReader(stream))
+		if _, err := dec.Decode(); err != nil {
+			t.Fatal(err)
+		}
+		_, err := dec.Decode()
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%q: expected a ParseError, got %v", stream, err)
+			continue
+		}
+		if parseErr.Offset != expected.offset {
+			t.Errorf("%q: expected offset %d, got %d (%v)", stream, expected.offset, parseErr.Offset, err)
+		}
+		if expected.err != nil && !errors.Is(err, expected.err) {
+			t.Errorf("%q: expected %v, got %v", stream, expected.err, err)
+		}
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	var s_expressions = []string{
+		"(11:certificate(6:issuer3:bob))",
+		"(4:file(1:*6:prefix6:/home/))",
+	}
+
+	enc := NewEncoder(&buf)
+	for _, expression := range s_expressions {
+		node, err := Parse([]byte(expression))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = enc.Encode(node); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if buf.String() != strings.Join(s_expressions, "") {
+		t.Errorf("unexpected output %s", buf.String())
+	}
+
+	dec := NewDecoder(&buf)
+	for _, expression := range s_expressions {
+		node, err := dec.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, _ := Marshal(node)
+		if string(out) != expression {
+			t.Errorf("expected %s, got %s", expression, out)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package spocp
+
+import (
+	"fmt"
+	"net/netip"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	addrType = reflect.TypeOf(netip.Addr{})
+)
+
+// MarshalStruct returns the canonical form of the S-expression NodeFromStruct
+// builds from v
+func MarshalStruct(v any) ([]byte, error) {
+	node, err := NodeFromStruct(v)
+	if err != nil {
+		return nil, err
+	}
+	return Marshal(&node)
+}
+
+// UnmarshalStruct parses data, in any form Parse accepts, and stores the
+// S-expression in the struct v points to, see NodeToStruct
+func UnmarshalStruct(data []byte, v any) error {
+	node, err := Parse(data)
+	if err != nil {
+		return err
+	}
+	return NodeToStruct(node, v)
+}
+
+// NodeFromStruct maps a struct, or a pointer to one, to an S-expression.
+// The tag of the S-expression is the lowercased type name, or the spocp
+// tag of a blank field:
+//
+//	type Certificate struct {
+//		_       struct{}  `spocp:"certificate"`
+//		Issuer  string    `spocp:"issuer"`
+//		Expires time.Time `spocp:"expires,omitempty"`
+//		Subject Subject   `spocp:"subject"`
+//	}
+//
+// Each exported field becomes a list of its tag followed by its value,
+// (6:issuer3:bob), in field order. A field without a tag uses its
+// lowercased name, the tag "-" skips it. The values are in the formats the
+// range limits use: integers in decimal, a time.Time in RFC 3339 or, with
+// the option time, as 15:04:05, and a netip.Addr as its string form. A
+// negative integer is written with a leading '-', which no numeric range
+// accepts, and the zero netip.Addr is an error unless omitempty leaves it
+// out. A nested struct becomes a list of its fields, a slice a list of all
+// its values. The option omitempty leaves out zero values and nil pointers
+// are always left out.
+func NodeFromStruct(v any) (Node, error) {
+	val := reflect.ValueOf(v)
+	for val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return Node{}, fmt.Errorf("marshal struct: nil %s", val.Type())
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return Node{}, fmt.Errorf("marshal struct: %s is not a struct", val.Type())
+	}
+	parts, err := structParts(val)
+	if err != nil {
+		return Node{}, err
+	}
+	return List(structTag(val.Type()), parts...)
+}
+
+// NodeToStruct stores the parts of node in the fields of the struct v
+// points to, the reverse of NodeFromStruct. The tag of node must be the
+// tag of the struct. Parts without a matching field are ignored.
+func NodeToStruct(node *Node, v any) error {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Pointer || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("unmarshal struct: %T is not a pointer to a struct", v)
+	}
+	val = val.Elem()
+	if node.kind != KindSExpression {
+		return fmt.Errorf("unmarshal struct: %s is not an s-expression", node.kind)
+	}
+	if tag := structTag(val.Type()); string(node.octet.Value) != tag {
+		return fmt.Errorf("unmarshal struct: expected tag %q, found %q", tag, node.octet.Value)
+	}
+	return setStruct(val, node.parts)
+}
+
+// structField is an exported field with its spocp tag
+type structField struct {
+	index     int
+	name      string
+	omitEmpty bool
+	time      bool
+}
+
+func structTag(typ reflect.Type) string {
+	for i := 0; i < typ.NumField(); i++ {
+		if field := typ.Field(i); field.Name == "_" {
+			if tag := field.Tag.Get("spocp"); tag != "" {
+				return tag
+			}
+		}
+	}
+	return strings.ToLower(typ.Name())
+}
+
+func structFields(typ reflect.Type) []structField {
+	var fields []structField
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("spocp")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		sf := structField{index: i, name: name}
+		for _, option := range strings.Split(options, ",") {
+			switch option {
+			case "omitempty":
+				sf.omitEmpty = true
+			case "time":
+				sf.time = true
+			}
+		}
+		fields = append(fields, sf)
+	}
+	return fields
+}
+
+func structParts(val reflect.Value) ([]Node, error) {
+	var parts []Node
+
+	for _, field := range structFields(val.Type()) {
+		fv := val.Field(field.index)
+		if field.omitEmpty && fv.IsZero() {
+			continue
+		}
+		for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Pointer {
+			// nil pointers are left out
+			continue
+		}
+		values, err := fieldValues(fv, field)
+		if err != nil {
+			return nil, err
+		}
+		part, err := List(field.name, values...)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+// fieldValues returns the parts following the tag of a field
+func fieldValues(fv reflect.Value, field structField) ([]Node, error) {
+	switch {
+	case fv.Kind() == reflect.Struct && fv.Type() != timeType && fv.Type() != addrType:
+		return structParts(fv)
+	case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8:
+		values := make([]Node, 0, fv.Len())
+		for i := 0; i < fv.Len(); i++ {
+			value, err := scalarValue(fv.Index(i), field)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, Atom(value))
+		}
+		return values, nil
+	}
+	value, err := scalarValue(fv, field)
+	if err != nil {
+		return nil, err
+	}
+	return []Node{Atom(value)}, nil
+}
+
+func scalarValue(fv reflect.Value, field structField) ([]byte, error) {
+	switch fv.Type() {
+	case timeType:
+		if field.time {
+			return []byte(fv.Interface().(time.Time).Format(timeOfDay)), nil
+		}
+		return []byte(fv.Interface().(time.Time).Format(time.RFC3339)), nil
+	case addrType:
+		addr := fv.Interface().(netip.Addr)
+		if !addr.IsValid() {
+			return nil, fmt.Errorf("marshal struct: field %s: invalid address", field.name)
+		}
+		return []byte(addr.String()), nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		return []byte(fv.String()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.AppendInt(nil, fv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.AppendUint(nil, fv.Uint(), 10), nil
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() == reflect.Uint8 {
+			return fv.Bytes(), nil
+		}
+	}
+	return nil, fmt.Errorf("marshal struct: field %s: unsupported type %s", field.name, fv.Type())
+}
+
+func setStruct(val reflect.Value, parts []Node) error {
+	fields := structFields(val.Type())
+	for _, part := range parts {
+		if part.kind != KindSExpression {
+			continue
+		}
+		for _, field := range fields {
+			if field.name != string(part.octet.Value) {
+				continue
+			}
+			if err := setField(val.Field(field.index), field, part.parts); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// setField stores the parts following the tag of a field
+func setField(fv reflect.Value, field structField, values []Node) error {
+	for fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		fv = fv.Elem()
+	}
+	switch {
+	case fv.Kind() == reflect.Struct && fv.Type() != timeType && fv.Type() != addrType:
+		return setStruct(fv, values)
+	case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8:
+		slice := reflect.MakeSlice(fv.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setScalar(slice.Index(i), field, value); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	}
+	if len(values) != 1 {
+		return fmt.Errorf("unmarshal struct: field %s: expected one value, found %d", field.name, len(values))
+	}
+	return setScalar(fv, field, values[0])
+}
+
+func setScalar(fv reflect.Value, field structField, value Node) error {
+	if value.kind != KindOctetString {
+		return fmt.Errorf("unmarshal struct: field %s: cannot store %s in %s", field.name, value.kind, fv.Type())
+	}
+	text := string(value.octet.Value)
+
+	var err error
+	switch fv.Type() {
+	case timeType:
+		layout := time.RFC3339
+		if field.time {
+			layout = timeOfDay
+		}
+		var t time.Time
+		if t, err = time.Parse(layout, text); err == nil {
+			fv.Set(reflect.ValueOf(t))
+		}
+	case addrType:
+		var addr netip.Addr
+		if addr, err = netip.ParseAddr(text); err == nil {
+			fv.Set(reflect.ValueOf(addr))
+		}
+	default:
+		switch fv.Kind() {
+		case reflect.String:
+			fv.SetString(text)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			var n int64
+			if n, err = strconv.ParseInt(text, 10, fv.Type().Bits()); err == nil {
+				fv.SetInt(n)
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			var n uint64
+			if n, err = strconv.ParseUint(text, 10, fv.Type().Bits()); err == nil {
+				fv.SetUint(n)
+			}
+		case reflect.Slice:
+			if fv.Type().Elem().Kind() != reflect.Uint8 {
+				return fmt.Errorf("unmarshal struct: field %s: unsupported type %s", field.name, fv.Type())
+			}
+			fv.SetBytes(append([]byte(nil), value.octet.Value...))
+		default:
+			return fmt.Errorf("unmarshal struct: field %s: unsupported type %s", field.name, fv.Type())
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("unmarshal struct: field %s: %w", field.name, err)
+	}
+	return nil
+}
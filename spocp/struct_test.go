@@ -0,0 +1,111 @@
+package spocp
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type subject struct {
+	Name string `spocp:"name"`
+	Age  uint8
+}
+
+type certificate struct {
+	_       struct{}   `spocp:"certificate"`
+	Issuer  string     `spocp:"issuer"`
+	Level   int        `spocp:"level"`
+	Expires time.Time  `spocp:"expires,omitempty"`
+	Opens   time.Time  `spocp:"opens,time"`
+	Host    netip.Addr `spocp:"host"`
+	Subject subject    `spocp:"subject"`
+	Roles   []string   `spocp:"roles"`
+	Key     []byte     `spocp:"key,omitempty"`
+	Comment *string    `spocp:"comment"`
+	Secret  string     `spocp:"-"`
+	hidden  string
+}
+
+func TestMarshalStruct(t *testing.T) {
+	cert := certificate{
+		Issuer:  "bob",
+		Level:   100,
+		Expires: time.Date(2030, 12, 31, 23, 59, 59, 0, time.UTC),
+		Opens:   time.Date(0, 1, 1, 10, 30, 0, 0, time.UTC),
+		Host:    netip.MustParseAddr("192.168.1.10"),
+		Subject: subject{Name: "alice", Age: 42},
+		Roles:   []string{"admin", "user"},
+		Secret:  "secret",
+		hidden:  "hidden",
+	}
+	expected := "(11:certificate(6:issuer3:bob)(5:level3:100)(7:expires20:2030-12-31T23:59:59Z)(5:opens8:10:30:00)" +
+		"(4:host12:192.168.1.10)(7:subject(4:name5:alice)(3:age2:42))(5:roles5:admin4:user))"
+
+	data, err := MarshalStruct(&cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != expected {
+		t.Fatalf("expected %s, got %s", expected, data)
+	}
+
+	var decoded certificate
+	if err = UnmarshalStruct(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	cert.Secret, cert.hidden = "", ""
+	if !reflect.DeepEqual(decoded, cert) {
+		t.Errorf("expected %+v, got %+v", cert, decoded)
+	}
+
+	// the values can be matched against ranges
+	rule, err := Parse([]byte("(11:certificate(6:issuer3:bob)(5:level(1:*5:range7:numeric2:ge2:50)))"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	query, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := Match(query, rule); err != nil || !ok {
+		t.Errorf("expected a match, got %v, %v", ok, err)
+	}
+}
+
+func TestMarshalStructDefaultTag(t *testing.T) {
+	data, err := MarshalStruct(subject{Name: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "(7:subject(4:name5:alice)(3:age1:0))" {
+		t.Errorf("unexpected %s", data)
+	}
+}
+
+func TestUnmarshalStructInvalid(t *testing.T) {
+	var cert certificate
+	var sub subject
+
+	inputs := map[string]any{
+		"(7:subject(4:name5:alice))":                 &cert,
+		"(11:certificate(5:level3:ten))":             &cert,
+		"(11:certificate(5:level1:12:2))":            &cert,
+		"(11:certificate(7:expires10:2030-12-31))":   &cert,
+		"(11:certificate(4:host9:localhost))":        &cert,
+		"(11:certificate(6:issuer(1:*6:prefix1:b)))": &cert,
+		"(7:subject(3:age3:256))":                    &sub,
+		"(7:subject(4:name3:bob))":                   sub,
+	}
+	for input, v := range inputs {
+		if err := UnmarshalStruct([]byte(input), v); err == nil {
+			t.Errorf("%s: expected an error", input)
+		}
+	}
+	if _, err := MarshalStruct(struct{ C chan int }{}); err == nil {
+		t.Error("expected an error for an unsupported type")
+	}
+	if _, err := MarshalStruct(struct{ Addr netip.Addr }{}); err == nil {
+		t.Error("expected an error for the zero address")
+	}
+}
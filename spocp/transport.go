@@ -0,0 +1,57 @@
+package spocp
+
+import (
+	"bytes"
+	"encoding/base64"
+)
+
+var LeftBrace byte = '{'
+var RightBrace byte = '}'
+
+// parseTransport parses an S-expression in transport form, the canonical
+// form base64 encoded within braces, e.g. {KDE6YTE6Yik=}. Whitespace within
+// the braces is ignored. Errors in the decoded S-expression are reported
+// with offsets into the decoded canonical form.
+func (p *Parser) parseTransport(data []byte) (*Node, error) {
+	inp := input{bs: data}
+	for inp.Remaining() > 0 && isSpace(inp.NextByte()) {
+		inp.currentPosition++
+	}
+	if inp.Remaining() == 0 || inp.NextByte() != LeftBrace {
+		return nil, newParseError(&inp, inp.currentPosition, "'{'", nil)
+	}
+	start := inp.currentPosition
+	end := bytes.IndexByte(inp.RemainingBytes(), RightBrace)
+	if end == -1 {
+		return nil, newParseError(&inp, len(data), "'}'", nil)
+	}
+	end += start
+	if len(bytes.TrimLeft(data[end+1:], " \t\r\n\f\v")) > 0 {
+		return nil, newParseError(&inp, end+1, "end of input", nil)
+	}
+
+	encoded := make([]byte, 0, end-start)
+	for _, c := range data[start+1 : end] {
+		if !isSpace(c) {
+			encoded = append(encoded, c)
+		}
+	}
+	decoded, err := decodeBase64(encoded)
+	if err != nil {
+		return nil, newParseError(&inp, start+1, "base64 encoded canonical S-expression", err)
+	}
+	return p.parseCanonical(decoded)
+}
+
+// MarshalTransport returns the transport form of node, the canonical form
+// base64 encoded within braces
+func MarshalTransport(node *Node) ([]byte, error) {
+	canonical, err := Marshal(node)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, base64.StdEncoding.EncodedLen(len(canonical))+2)
+	out = append(out, LeftBrace)
+	out = base64.StdEncoding.AppendEncode(out, canonical)
+	return append(out, RightBrace), nil
+}
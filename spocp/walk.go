@@ -0,0 +1,95 @@
+package spocp
+
+import (
+	"errors"
+)
+
+// SkipChildren is returned by a WalkFunc to leave out the parts of the node
+// it was called for
+var SkipChildren = errors.New("skip children")
+
+// WalkFunc is called by Walk for every node. path holds the tags of the
+// enclosing lists, as in ParseError, with "*set" for an enclosing set, and
+// depth its length. The path may be retained.
+type WalkFunc func(node *Node, path []string, depth int) error
+
+// Walk calls fn for node and all the nodes below it, parents before their
+// parts. It stops at the first error fn returns other than SkipChildren
+// and returns it.
+func Walk(node *Node, fn WalkFunc) error {
+	return walk(node, nil, fn)
+}
+
+func walk(node *Node, path []string, fn WalkFunc) error {
+	err := fn(node, path, len(path))
+	if err == SkipChildren {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if node.kind != KindSExpression && node.kind != KindSet {
+		return nil
+	}
+	path = append(path[:len(path):len(path)], pathElement(node))
+	for i := range node.parts {
+		if err = walk(&node.parts[i], path, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Inspect calls fn for node and all the nodes below it, like Walk. The
+// parts of a node are left out when fn returns false.
+func Inspect(node *Node, fn func(node *Node, path []string, depth int) bool) {
+	_ = Walk(node, func(node *Node, path []string, depth int) error {
+		if !fn(node, path, depth) {
+			return SkipChildren
+		}
+		return nil
+	})
+}
+
+// Rewrite returns a copy of node with every node replaced by what fn
+// returns for it. fn is called for the parts of a node first and then for
+// the node with its rewritten parts. Returning the zero Node removes the
+// node from its list or set. The rewritten sets must remain valid, see
+// SetOf. node itself is not modified.
+func Rewrite(node Node, fn func(node Node, path []string, depth int) (Node, error)) (Node, error) {
+	return rewrite(node, nil, fn)
+}
+
+func rewrite(node Node, path []string, fn func(Node, []string, int) (Node, error)) (Node, error) {
+	if node.kind == KindSExpression || node.kind == KindSet {
+		inner := append(path[:len(path):len(path)], pathElement(&node))
+		parts := make([]Node, 0, len(node.parts))
+		for _, part := range node.parts {
+			part, err := rewrite(part, inner, fn)
+			if err != nil {
+				return Node{}, err
+			}
+			if part.kind != KindInvalid {
+				parts = append(parts, part)
+			}
+		}
+		if node.kind == KindSet {
+			set, err := SetOf(parts...)
+			if err != nil {
+				return Node{}, err
+			}
+			node = set
+		} else {
+			node = newSExpression(node.octet, parts)
+		}
+	}
+	return fn(node, path, len(path))
+}
+
+// pathElement returns the name a list or set has in a path
+func pathElement(node *Node) string {
+	if node.kind == KindSet {
+		return "*" + SetStarform
+	}
+	return string(node.octet.Value)
+}
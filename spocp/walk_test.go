@@ -0,0 +1,105 @@
+package spocp
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	node, err := Parse([]byte("(certificate (issuer bob) (subject (name alice)) (fruit (* set apple (lemon sour))) (level (* range numeric ge 100)))"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var visited []string
+	err = Walk(node, func(node *Node, path []string, depth int) error {
+		if depth != len(path) {
+			t.Errorf("depth %d for path %v", depth, path)
+		}
+		visited = append(visited, strings.Join(path, "/")+" "+node.Kind().String())
+		if node.Kind() == KindSExpression && string(node.Tag().Value) == "subject" {
+			return SkipChildren
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		" sexpression",
+		"certificate sexpression",
+		"certificate/issuer octet_string",
+		"certificate sexpression",
+		"certificate sexpression",
+		"certificate/fruit set",
+		"certificate/fruit/*set octet_string",
+		"certificate/fruit/*set sexpression",
+		"certificate/fruit/*set/lemon octet_string",
+		"certificate sexpression",
+		"certificate/level range",
+	}
+	if strings.Join(visited, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(visited, "\n"))
+	}
+
+	stop := errors.New("stop")
+	count := 0
+	err = Walk(node, func(node *Node, path []string, depth int) error {
+		count++
+		if node.Kind() == KindSet {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || count != 8 {
+		t.Errorf("expected to stop at the set, got %v after %d nodes", err, count)
+	}
+
+	count = 0
+	Inspect(node, func(node *Node, path []string, depth int) bool {
+		count++
+		return depth == 0
+	})
+	if count != 5 {
+		t.Errorf("expected 5 nodes, got %d", count)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	node, err := Parse([]byte("(certificate (issuer bob) (secret xyz) (fruit (* set apple lemon)))"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := node.Clone()
+
+	rewritten, err := Rewrite(*node, func(node Node, path []string, depth int) (Node, error) {
+		switch {
+		case node.Kind() == KindSExpression && string(node.Tag().Value) == "secret":
+			return Node{}, nil
+		case node.Kind() == KindOctetString && len(path) > 0 && path[len(path)-1] == "*set":
+			return Atom(bytes.ToUpper(node.Octet().Value)), nil
+		}
+		return node, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rewritten.String(); got != "(certificate (issuer bob) (fruit (* set APPLE LEMON)))" {
+		t.Errorf("unexpected %s", got)
+	}
+	if !node.Equal(original) {
+		t.Errorf("expected the original to be unchanged, got %s", node)
+	}
+
+	_, err = Rewrite(*node, func(node Node, path []string, depth int) (Node, error) {
+		if node.Kind() == KindOctetString && len(path) > 0 && path[len(path)-1] == "*set" {
+			return Atom([]byte("same")), nil
+		}
+		return node, nil
+	})
+	if err == nil {
+		t.Error("expected an error for a set with duplicate members")
+	}
+}